@@ -17,6 +17,8 @@ type Config struct {
 	ExcludeDomains       []string      `env:"EXCLUDE_DOMAIN_FILTER" envDefault:""`
 	RegexDomainFilter    string        `env:"REGEXP_DOMAIN_FILTER" envDefault:""`
 	RegexDomainExclusion string        `env:"REGEXP_DOMAIN_FILTER_EXCLUSION" envDefault:""`
+	TracingExporter      string        `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingSampleRatio   float64       `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
 }
 
 // Init sets up configuration by reading set environmental variables
@@ -41,7 +41,7 @@ func Init(config configuration.Config) (provider.Provider, error) {
 
 	createMsg = strings.TrimSuffix(createMsg, ", ")
 	if strings.HasSuffix(createMsg, "with ") {
-		createMsg += "no kind of domain filters"
+		createMsg += "no kind of domain filters, zones are discovered from the ddi"
 	}
 	log.Info(createMsg)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,27 +19,27 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// baseCtx is the parent of every request context of the main server. It is
+// canceled when a graceful shutdown runs out of time, aborting in-flight DDI
+// calls.
+var baseCtx, cancelBaseCtx = context.WithCancel(context.Background())
+
 // HealthCheckHandler returns the status of the service
 func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
 }
 
-// ReadinessHandler returns whether the service is ready to accept requests
-func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("OK"))
-}
-
 // Init initializes the http server
 func Init(config configuration.Config, p *webhook.Webhook) (*http.Server, *http.Server) {
 	mainRouter := chi.NewRouter()
-	mainRouter.Get("/", p.Negotiate)
-	mainRouter.Get("/records", p.Records)
-	mainRouter.Post("/records", p.ApplyChanges)
-	mainRouter.Post("/adjustendpoints", p.AdjustEndpoints)
+	mainRouter.Get("/", webhook.Traced("webhook.Negotiate", p.Negotiate))
+	mainRouter.Get("/records", webhook.Traced("webhook.Records", p.Records))
+	mainRouter.Post("/records", webhook.Traced("webhook.ApplyChanges", p.ApplyChanges))
+	mainRouter.Post("/adjustendpoints", webhook.Traced("webhook.AdjustEndpoints", p.AdjustEndpoints))
 
 	mainServer := createHTTPServer(fmt.Sprintf("%s:%d", config.ServerHost, config.ServerPort), mainRouter, config.ServerReadTimeout, config.ServerWriteTimeout)
+	mainServer.BaseContext = func(net.Listener) context.Context { return baseCtx }
 	go func() {
 		log.Infof("starting server on addr: '%s' ", mainServer.Addr)
 		if err := mainServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -49,7 +50,9 @@ func Init(config configuration.Config, p *webhook.Webhook) (*http.Server, *http.
 	healthRouter := chi.NewRouter()
 	healthRouter.Get("/metrics", promhttp.Handler().ServeHTTP)
 	healthRouter.Get("/healthz", HealthCheckHandler)
-	healthRouter.Get("/readyz", ReadinessHandler)
+	healthRouter.Get("/readyz", p.Readiness)
+	healthRouter.Post("/zones/invalidate", p.InvalidateZoneCache)
+	healthRouter.Get("/dryrun", p.PlannedRequests)
 
 	healthServer := createHTTPServer("0.0.0.0:8080", healthRouter, config.ServerReadTimeout, config.ServerWriteTimeout)
 	go func() {
@@ -83,6 +86,7 @@ func ShutdownGracefully(mainServer *http.Server, healthServer *http.Server) {
 
 	if err := mainServer.Shutdown(ctx); err != nil {
 		log.Errorf("error shutting down main server: %v", err)
+		cancelBaseCtx()
 	}
 
 	if err := healthServer.Shutdown(ctx); err != nil {
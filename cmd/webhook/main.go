@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/cmd/webhook/init/configuration"
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/cmd/webhook/init/dnsprovider"
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/cmd/webhook/init/logging"
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/cmd/webhook/init/server"
+	"github.com/Yamu-OSS/external-dns-yamu-webhook/cmd/webhook/init/tracing"
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/webhook"
 	log "github.com/sirupsen/logrus"
 )
@@ -28,6 +30,16 @@ func main() {
 	logging.Init()
 
 	config := configuration.Init()
+	shutdownTracing, err := tracing.Init(config)
+	if err != nil {
+		log.Fatalf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Errorf("failed to flush traces: %v", err)
+		}
+	}()
+
 	provider, err := dnsprovider.Init(config)
 	if err != nil {
 		log.Fatalf("failed to initialize provider: %v", err)
@@ -0,0 +1,137 @@
+package ddi
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned without contacting YamuDDI while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("ddi circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// circuitBreaker stops calls to YamuDDI after repeated failures. While open it
+// fails fast; after openTimeout a single probe request is let through, which
+// closes the breaker on success and opens it again on failure.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	state       breakerState
+	failures    int
+	probing     bool
+	openedAt    time.Time
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+}
+
+// newCircuitBreaker returns a breaker that opens after threshold consecutive
+// failures. A threshold below 1 disables it.
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+	ddiCircuitBreakerState.Set(float64(breakerClosed))
+
+	return b
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if not.
+func (b *circuitBreaker) allow() error {
+	if b.threshold < 1 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record notes the outcome of an allowed request.
+func (b *circuitBreaker) record(failed bool) {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.setState(breakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(breakerOpen)
+	}
+}
+
+// release returns an allowed request whose outcome says nothing about
+// YamuDDI, such as one canceled by the caller.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State returns the current state of the breaker.
+func (b *circuitBreaker) State() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+
+	log.Warnf("circuit breaker: %s -> %s", b.state, state)
+	b.state = state
+	ddiCircuitBreakerState.Set(float64(state))
+}
@@ -0,0 +1,61 @@
+package ddi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("allow() while closed = %v", err)
+		}
+		b.record(true)
+	}
+	if b.State() != breakerOpen {
+		t.Fatalf("state after 2 failures = %s, want open", b.State())
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() while open = %v, want ErrCircuitOpen", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() probe = %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() during probe = %v, want ErrCircuitOpen", err)
+	}
+	b.record(true)
+	if b.State() != breakerOpen {
+		t.Fatalf("state after failed probe = %s, want open", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() probe = %v", err)
+	}
+	b.release()
+	if err := b.allow(); err != nil {
+		t.Fatalf("allow() after released probe = %v", err)
+	}
+	b.record(false)
+	if b.State() != breakerClosed {
+		t.Fatalf("state after successful probe = %s, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.record(true)
+	}
+	if err := b.allow(); err != nil {
+		t.Errorf("allow() on disabled breaker = %v", err)
+	}
+}
@@ -2,16 +2,23 @@ package ddi
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -19,41 +26,147 @@ var (
 	apiRRCreate  = "zone/auth/rr/view/%s/zone/%s"
 	apiRRDel     = apiRRCreate
 	apiRRGet     = "zone/auth/rr/all/view/%s/zone/%s?source=%s"
+	apiRRGetAll  = "zone/auth/rr/all/view/%s/zone/%s"
 	apiZoneGet   = "zone/auth/view/%s/zone/%s"
+	apiZoneList  = "zone/auth/view/%s"
 )
 
+// apiTemplates are the request paths matched by parseEndpoint, longest first.
+var apiTemplates = []string{apiRRGetAll, apiRRCreate, apiZoneGet, apiZoneList}
+
+// apiEndpoint is a request path split into the API template it was built
+// from and the view and zone it refers to.
+type apiEndpoint struct {
+	Template string
+	View     string
+	Zone     string
+}
+
+// parseEndpoint matches the request path p against the API templates.
+func (c *httpClient) parseEndpoint(p string) apiEndpoint {
+	p, _, _ = strings.Cut(strings.TrimPrefix(p, c.baseURL.Path), "?")
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+
+	for _, tmpl := range apiTemplates {
+		tsegs := strings.Split(tmpl, "/")
+		if len(tsegs) != len(segs) {
+			continue
+		}
+
+		var args []string
+		for i, ts := range tsegs {
+			if ts == "%s" {
+				args = append(args, segs[i])
+			} else if ts != segs[i] {
+				args = nil
+				break
+			}
+		}
+		if args == nil {
+			continue
+		}
+
+		ep := apiEndpoint{
+			Template: strings.Replace(strings.Replace(tmpl, "%s", "{view}", 1), "%s", "{zone}", 1),
+			View:     args[0],
+		}
+		if len(args) > 1 {
+			ep.Zone = args[1]
+		}
+		return ep
+	}
+
+	return apiEndpoint{Template: "unknown"}
+}
+
 // httpClient is the DNS provider client.
 type httpClient struct {
 	*Config
 	*http.Client
+	// baseURL is the URL of the first node; request paths are built from it.
 	baseURL *url.URL
+	nodes   *nodePool
+	creds   *credentials
+	breaker *circuitBreaker
+
+	readLimiter  *requestLimiter
+	writeLimiter *requestLimiter
+
+	dryRun *dryRunLog
+
+	unknownAuthority sync.Once
 }
 
 // newYamuDDIClient creates a new DNS provider client.
 func newYamuDDIClient(config *Config) (*httpClient, error) {
-	u, err := url.Parse(config.Host)
+	nodes, err := newNodePool(config.Host)
 	if err != nil {
-		return nil, fmt.Errorf("parse url: %w", err)
+		return nil, err
 	}
-	u.Path = path.Join(u.Path, apiDnsPrefix)
+
+	creds, err := newCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, tf, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
 
 	// Create the HTTP client
 	client := &httpClient{
 		Config: config,
 		Client: &http.Client{
-			Timeout: time.Duration(config.OpenAPITimeout) * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.SkipTLSVerify},
-			},
+			Transport: transport,
 		},
-		baseURL: u,
+		baseURL: nodes.nodes[0].url,
+		nodes:   nodes,
+		creds:   creds,
+		breaker: newCircuitBreaker(config.BreakerFailures, config.BreakerOpenTimeout),
+		dryRun:  &dryRunLog{},
+	}
+
+	if config.WriteRateLimit > 0 || config.WriteMaxConcurrency > 0 {
+		client.readLimiter = newRequestLimiter("read", config.RateLimit, config.RateBurst, config.MaxConcurrency)
+		client.writeLimiter = newRequestLimiter("write", config.WriteRateLimit, config.WriteRateBurst, config.WriteMaxConcurrency)
+	} else {
+		client.readLimiter = newRequestLimiter("all", config.RateLimit, config.RateBurst, config.MaxConcurrency)
+	}
+
+	if tf != nil && config.FileReloadInterval > 0 {
+		go watchFiles(context.Background(), config.FileReloadInterval, tf.files(), func() {
+			if err := tf.load(); err != nil {
+				log.Errorf("tls: reload certificates: %v", err)
+				return
+			}
+			// New connections pick up the new certificates
+			transport.CloseIdleConnections()
+			log.Info("tls: certificates reloaded")
+		})
+	}
+
+	if files := creds.files(); len(files) > 0 && config.FileReloadInterval > 0 {
+		go watchFiles(context.Background(), config.FileReloadInterval, files, func() {
+			if err := creds.load(); err != nil {
+				log.Errorf("credentials: reload: %v", err)
+				return
+			}
+			log.Infof("credentials: reloaded from %v", files)
+		})
+	}
+
+	if len(nodes.nodes) > 1 && config.HealthCheckInterval > 0 {
+		go client.healthCheck(context.Background(), config.HealthCheckInterval)
 	}
 
 	return client, nil
 }
 
-// doRequest makes an HTTP request to the Yamu firewall.
-func (c *httpClient) doRequest(method, path string, body []byte, data any) (err error) {
+// doRequest makes an HTTP request to the Yamu firewall. Idempotent requests
+// are retried on transient failures.
+func (c *httpClient) doRequest(ctx context.Context, method, path string, body []byte, data any) (err error) {
 	defer func() {
 		if err == nil {
 			return
@@ -62,22 +175,94 @@ func (c *httpClient) doRequest(method, path string, body []byte, data any) (err
 		log.Errorf("method: %s, path: %s, body: %s err %s", method, path, string(body), err)
 	}()
 
-	p, err := url.Parse(path)
+	if !isIdempotent(method) {
+		err = c.doRequestOnce(ctx, method, path, body, data)
+		if err != nil {
+			ddiRequestFailures.WithLabelValues(method).Inc()
+		}
+		return err
+	}
+
+	return c.retry(ctx, method, path, func() error {
+		return c.doRequestOnce(ctx, method, path, body, data)
+	}, nil)
+}
+
+// doRequestOnce makes a single HTTP request to the Yamu firewall unless the
+// circuit breaker is open, waiting for the rate limiter first. In DRY_RUN
+// mode mutating requests are only recorded.
+func (c *httpClient) doRequestOnce(ctx context.Context, method, path string, body []byte, data any) error {
+	if c.DryRun && method != http.MethodGet {
+		c.dryRun.record(method, path, body)
+		return nil
+	}
+
+	release, err := c.limiter(method).acquire(ctx)
 	if err != nil {
 		return err
 	}
+	defer release()
+
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+
+	ep := c.parseEndpoint(path)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fmt.Sprintf("ddi %s %s", method, ep.Template),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("ddi.endpoint", ep.Template),
+			attribute.String("ddi.view", ep.View),
+			attribute.String("ddi.zone", ep.Zone),
+		),
+	)
+	defer span.End()
+
+	n := c.nodes.pick(ctx)
+	span.SetAttributes(attribute.String("ddi.node", n.name))
+
+	start := time.Now()
+	err = c.send(ctx, n, method, path, body, data)
+	observeRequest(ctx, method, ep.Template, start, err)
+	endSpan(span, err)
+	if err != nil && ctx.Err() == nil && isNodeDown(err) {
+		c.nodes.fail(n)
+	}
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; this says nothing about YamuDDI
+		c.breaker.release()
+		return err
+	}
+	c.breaker.record(err != nil && isTransient(err))
 
-	u := c.baseURL.ResolveReference(p)
+	return err
+}
+
+// send makes a single HTTP request to the node n of the Yamu firewall.
+func (c *httpClient) send(ctx context.Context, n *node, method, path string, body []byte, data any) error {
+	p, err := url.Parse(path)
+	if err != nil {
+		return &permanentError{fmt.Errorf("doRequest: invalid path %s: %w", path, err)}
+	}
+
+	u := n.url.ResolveReference(p)
 	log.Debugf("doRequest: making %s request to %s", method, u)
 
-	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	// Every call gets its own deadline within the caller's context
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.OpenAPITimeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
 	if err != nil {
-		return err
+		return &permanentError{err}
 	}
 	c.setHeaders(req)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
+		c.logUnknownAuthority(err)
 		return err
 	}
 	defer resp.Body.Close()
@@ -87,31 +272,45 @@ func (c *httpClient) doRequest(method, path string, body []byte, data any) (err
 	if resp.StatusCode == http.StatusBadRequest {
 		var code respCode
 		if err = json.NewDecoder(resp.Body).Decode(&code); err != nil {
-			return err
+			return &apiError{StatusCode: resp.StatusCode, Description: fmt.Sprintf("doRequest: decode %s response from %s: %v", method, u, err)}
 		}
 
 		if code.RCode != 0 {
-			return fmt.Errorf("%s", code.Description)
+			return &apiError{StatusCode: resp.StatusCode, RCode: code.RCode, Description: code.Description}
 		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("doRequest: %s request to %s was not successful: %d", method, u, resp.StatusCode)
+		return &apiError{
+			StatusCode:  resp.StatusCode,
+			Description: fmt.Sprintf("doRequest: %s request to %s was not successful: %d", method, u, resp.StatusCode),
+			RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	if data == nil {
 		return nil
 	}
 
-	return json.NewDecoder(resp.Body).Decode(data)
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		// A connection lost while reading the body may be retried; an
+		// answer that is not what the API documents will not change.
+		if isTransient(err) {
+			return err
+		}
+		return &permanentError{fmt.Errorf("doRequest: decode %s response from %s: %w", method, u, err)}
+	}
+
+	return nil
 }
 
 // GetHostOverrides retrieves the list of records from the YamuDDI API.
-func (c *httpClient) GetHostOverrides(zone string) ([]*DNSRecord, error) {
-	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRGet, c.View, zone, source))
+func (c *httpClient) GetHostOverrides(ctx context.Context, view, zone string) ([]*DNSRecord, error) {
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRGet, view, zone, url.QueryEscape(c.owner())))
 
 	var records respRRs
 	err := c.doRequest(
+		ctx,
 		http.MethodGet,
 		p,
 		nil,
@@ -119,7 +318,7 @@ func (c *httpClient) GetHostOverrides(zone string) ([]*DNSRecord, error) {
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, withContext(err, "get records", view, zone, nil)
 	}
 
 	log.Debugf("gethost: retrieved records: %+v", len(records.Data))
@@ -127,36 +326,95 @@ func (c *httpClient) GetHostOverrides(zone string) ([]*DNSRecord, error) {
 	return records.Data, nil
 }
 
-// CreateHostOverride creates a new DNS A or AAAA or CNAME record in the YamuDDI API.
-func (c *httpClient) CreateHostOverride(zone string, rr *DNSRecord) error {
-	log.Debugf("create recored. zone: %s, rr-counts: 1", zone)
-	jsonBody, err := json.Marshal([]*DNSRecord{rr})
-	if err != nil {
-		return err
-	}
-	return c.createHostOverride(zone, jsonBody)
-}
+// GetAllHostOverrides retrieves the list of records from the YamuDDI API
+// regardless of their source.
+func (c *httpClient) GetAllHostOverrides(ctx context.Context, view, zone string) ([]*DNSRecord, error) {
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRGetAll, view, zone))
 
-// createHostOverride
-func (c *httpClient) createHostOverride(zone string, jsonBody []byte) error {
-	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRCreate, c.View, zone))
+	var records respRRs
 	err := c.doRequest(
-		http.MethodPost,
+		ctx,
+		http.MethodGet,
 		p,
-		jsonBody,
 		nil,
+		&records,
 	)
 
 	if err != nil {
-		return err
+		return nil, withContext(err, "get records", view, zone, nil)
 	}
 
-	return nil
+	log.Debugf("getallhost: retrieved records: %+v", len(records.Data))
+
+	return records.Data, nil
+}
+
+// CreateHostOverride creates a new DNS A or AAAA or CNAME record in the YamuDDI API.
+func (c *httpClient) CreateHostOverride(ctx context.Context, view, zone string, rr *DNSRecord) error {
+	log.Debugf("create recored. view: %s, zone: %s, rr-counts: 1", view, zone)
+	return c.createHostOverride(ctx, view, zone, []*DNSRecord{rr})
+}
+
+// CreateHostOverrideBulk creates DNS records in a single request to the YamuDDI API.
+func (c *httpClient) CreateHostOverrideBulk(ctx context.Context, view, zone string, rrs []*DNSRecord) error {
+	log.Debugf("create recored. view: %s, zone: %s, rr-counts: %d", view, zone, len(rrs))
+	return c.createHostOverride(ctx, view, zone, rrs)
+}
+
+// createHostOverride creates records. Creating is not idempotent, so after a
+// transient failure only the records a follow-up read does not find are sent
+// again.
+func (c *httpClient) createHostOverride(ctx context.Context, view, zone string, rrs []*DNSRecord) error {
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRCreate, view, zone))
+
+	pending := rrs
+	err := c.retry(ctx, http.MethodPost, p, func() error {
+		jsonBody, err := json.Marshal(pending)
+		if err != nil {
+			return err
+		}
+		return c.doRequestOnce(ctx, http.MethodPost, p, jsonBody, nil)
+	}, func() (bool, error) {
+		missing, err := c.missingRecords(ctx, view, zone, pending)
+		if err != nil {
+			return false, err
+		}
+		if len(missing) < len(pending) {
+			log.Infof("create: %d of %d records in zone %s view %s were written before the failure",
+				len(pending)-len(missing), len(pending), zone, view)
+		}
+		pending = missing
+		return len(pending) == 0, nil
+	})
+
+	return withContext(err, "create records", view, zone, pending)
+}
+
+// missingRecords returns the records of rrs that do not exist in the zone.
+func (c *httpClient) missingRecords(ctx context.Context, view, zone string, rrs []*DNSRecord) ([]*DNSRecord, error) {
+	existing, err := c.GetAllHostOverrides(ctx, view, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, rr := range existing {
+		found[recordKey(rr)] = true
+	}
+
+	missing := make([]*DNSRecord, 0, len(rrs))
+	for _, rr := range rrs {
+		if !found[recordKey(rr)] {
+			missing = append(missing, rr)
+		}
+	}
+
+	return missing, nil
 }
 
 // DeleteHostOverrideBulk deletes DNS records from the YamuDDI API.
-func (c *httpClient) DeleteHostOverrideBulk(zone string, rrs []*DNSRecord) error {
-	log.Debugf("create recored. zone: %s, rr-counts: %d", zone, len(rrs))
+func (c *httpClient) DeleteHostOverrideBulk(ctx context.Context, view, zone string, rrs []*DNSRecord) error {
+	log.Debugf("delete recored. view: %s, zone: %s, rr-counts: %d", view, zone, len(rrs))
 	jsonBody, err := json.Marshal(DNSRecordsDel{
 		RRs: rrs,
 	})
@@ -164,9 +422,10 @@ func (c *httpClient) DeleteHostOverrideBulk(zone string, rrs []*DNSRecord) error
 		return err
 	}
 
-	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRDel, c.View, zone))
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiRRDel, view, zone))
 
 	err = c.doRequest(
+		ctx,
 		http.MethodDelete,
 		p,
 		jsonBody,
@@ -174,39 +433,103 @@ func (c *httpClient) DeleteHostOverrideBulk(zone string, rrs []*DNSRecord) error
 	)
 
 	if err != nil {
-		return err
+		return withContext(err, "delete records", view, zone, rrs)
 	}
 
 	return nil
 }
 
-// ZoneExist checks if a zone exists in the DDI filter list.
-func (c *httpClient) ZoneExist(domain string) bool {
-	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiZoneGet, c.View, domain))
+// ZoneExist checks if a zone exists in the DDI filter list. An error is
+// returned when YamuDDI did not say whether the zone exists, for example
+// because it was unreachable or rejected the credentials, not when the zone
+// is missing.
+func (c *httpClient) ZoneExist(ctx context.Context, view, domain string) (bool, error) {
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiZoneGet, view, domain))
 	var code respCode
 
 	err := c.doRequest(
+		ctx,
 		http.MethodGet,
 		p,
 		nil,
 		&code,
 	)
+
+	if isZoneNotFound(err) {
+		return false, nil
+	}
 	if err != nil {
-		return false
+		return false, withContext(err, "get zone", view, domain, nil)
 	}
 
 	if code.RCode != 0 {
 		log.Errorf("ZoneExist Failed to get zone: %s", code.Description)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// isZoneNotFound reports whether err is the answer of YamuDDI to a request
+// for a zone that does not exist: 404, or 400 with a result code.
+func isZoneNotFound(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
 		return false
 	}
 
-	return true
+	return apiErr.StatusCode == http.StatusNotFound ||
+		apiErr.StatusCode == http.StatusBadRequest && apiErr.RCode != 0
+}
+
+// ListZones retrieves the authoritative zones of the view from the YamuDDI API.
+func (c *httpClient) ListZones(ctx context.Context, view string) ([]*Zone, error) {
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiZoneList, view))
+
+	var zones respZones
+	err := c.doRequest(
+		ctx,
+		http.MethodGet,
+		p,
+		nil,
+		&zones,
+	)
+
+	if err != nil {
+		return nil, withContext(err, "list zones", view, "", nil)
+	}
+
+	log.Debugf("listzones: retrieved zones: %+v", len(zones.Data))
+
+	return zones.Data, nil
+}
+
+// CreateZone creates an authoritative zone in the YamuDDI API.
+func (c *httpClient) CreateZone(ctx context.Context, view string, zone *Zone) error {
+	log.Debugf("create zone. view: %s, zone: %s", view, zone.Name)
+	jsonBody, err := json.Marshal(zone)
+	if err != nil {
+		return err
+	}
+
+	p := path.Join(c.baseURL.Path, fmt.Sprintf(apiZoneList, view))
+
+	err = c.doRequest(
+		ctx,
+		http.MethodPost,
+		p,
+		jsonBody,
+		nil,
+	)
+
+	return withContext(err, "create zone", view, zone.Name, nil)
 }
 
 // setHeaders sets the headers for the HTTP request.
 func (c *httpClient) setHeaders(req *http.Request) {
 	// Add basic auth header
-	yamuDDIAuth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.Config.User, c.Config.Key)))
+	user, key := c.creds.get()
+	yamuDDIAuth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", user, key)))
 	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", yamuDDIAuth))
 	req.Header.Add("Accept", "application/json")
 	if req.Method != http.MethodGet {
@@ -1,6 +1,12 @@
 package ddi
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
 
 var client *httpClient
 var addRRs map[string]*DNSRecord
@@ -28,7 +34,7 @@ func init() {
 			TTLStrategy: strategyInherit,
 			Rdata:       "123.123.123.123",
 			Enabled:     true,
-			Source:      source,
+			Source:      defaultOwnerID,
 		},
 		"testAAAA": {
 			Name:        "www",
@@ -37,7 +43,7 @@ func init() {
 			TTLStrategy: strategyRewrite,
 			Rdata:       "2001:db8::1",
 			Enabled:     true,
-			Source:      source,
+			Source:      defaultOwnerID,
 		},
 		"testCNAME": {
 			Name:        "cname",
@@ -46,7 +52,16 @@ func init() {
 			TTLStrategy: strategyRewrite,
 			Rdata:       "abc.com",
 			Enabled:     true,
-			Source:      source,
+			Source:      defaultOwnerID,
+		},
+		"testTXT": {
+			Name:        "txt",
+			Rtype:       "TXT",
+			TTL:         30,
+			TTLStrategy: strategyRewrite,
+			Rdata:       `"heritage=external-dns,external-dns/owner=default"`,
+			Enabled:     true,
+			Source:      defaultOwnerID,
 		},
 	}
 }
@@ -54,7 +69,7 @@ func init() {
 func TestCreateHostOverride(t *testing.T) {
 	t.Skip("need a real server to test")
 	for tName, rr := range addRRs {
-		err := client.CreateHostOverride("test.com", rr)
+		err := client.CreateHostOverride(context.Background(), client.View, "test.com", rr)
 		if err != nil {
 			t.Errorf("TestCreateHostOverride=%v, test=%v", err, tName)
 		}
@@ -63,7 +78,7 @@ func TestCreateHostOverride(t *testing.T) {
 
 func TestGetHostOverrides(t *testing.T) {
 	t.Skip("need a real server to test")
-	rrs, err := client.GetHostOverrides("test.com")
+	rrs, err := client.GetHostOverrides(context.Background(), client.View, "test.com")
 	if err != nil {
 		t.Errorf("TestCreateHostOverride=%v, wantNumOfRRs!=%v", err, len(rrs))
 	}
@@ -72,9 +87,162 @@ func TestGetHostOverrides(t *testing.T) {
 func TestDeleteHostOverrideBulk(t *testing.T) {
 	t.Skip("need a real server to test")
 	for tName, rr := range addRRs {
-		err := client.DeleteHostOverrideBulk("test.com", []*DNSRecord{rr})
+		err := client.DeleteHostOverrideBulk(context.Background(), client.View, "test.com", []*DNSRecord{rr})
 		if err != nil {
 			t.Errorf("TestDeleteHostOverrideBulk=%v, test=%v", err, tName)
 		}
 	}
 }
+
+// newTestClient returns a client talking to a test server serving handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *httpClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := newYamuDDIClient(&Config{
+		Host:           srv.URL,
+		User:           "admin",
+		Key:            "123456",
+		OpenAPITimeout: 5,
+		View:           "default",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// newTestProvider returns a provider whose default view has the given zones,
+// talking to a test server serving handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc, zones ...string) *Provider {
+	t.Helper()
+	c := newTestClient(t, handler)
+	c.ZoneRefreshInterval = time.Hour
+
+	return &Provider{
+		client:                 c,
+		domainFilterDDI:        map[string][]string{"default": zones},
+		domainFilterDDIUpdated: time.Now(),
+		zoneCache:              newZoneCache(0, 0),
+		recordCache:            newRecordCache(0),
+		config:                 c.Config,
+	}
+}
+
+func TestZoneExist(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "exists",
+			status: http.StatusOK,
+			body:   `{"rcode":0}`,
+			want:   true,
+		},
+		{
+			name:   "missing",
+			status: http.StatusBadRequest,
+			body:   `{"rcode":1,"description":"zone not found"}`,
+			want:   false,
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+			want:   false,
+		},
+		{
+			name:    "bad request without rcode",
+			status:  http.StatusBadRequest,
+			body:    `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "unauthorized",
+			status:  http.StatusUnauthorized,
+			wantErr: true,
+		},
+		{
+			name:    "forbidden",
+			status:  http.StatusForbidden,
+			wantErr: true,
+		},
+		{
+			name:    "rate limited",
+			status:  http.StatusTooManyRequests,
+			wantErr: true,
+		},
+		{
+			name:    "outage",
+			status:  http.StatusBadGateway,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			got, err := c.ZoneExist(context.Background(), "default", "test.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ZoneExist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ZoneExist() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	c, err := newYamuDDIClient(&Config{Host: "https://127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want apiEndpoint
+	}{
+		{
+			name: "records of owner",
+			path: "/openapi/dns/zone/auth/rr/all/view/default/zone/test.com?source=external-dns-yamu",
+			want: apiEndpoint{Template: "zone/auth/rr/all/view/{view}/zone/{zone}", View: "default", Zone: "test.com"},
+		},
+		{
+			name: "record changes",
+			path: "/openapi/dns/zone/auth/rr/view/default/zone/test.com",
+			want: apiEndpoint{Template: "zone/auth/rr/view/{view}/zone/{zone}", View: "default", Zone: "test.com"},
+		},
+		{
+			name: "zone",
+			path: "/openapi/dns/zone/auth/view/internal/zone/test.com",
+			want: apiEndpoint{Template: "zone/auth/view/{view}/zone/{zone}", View: "internal", Zone: "test.com"},
+		},
+		{
+			name: "zones",
+			path: "/openapi/dns/zone/auth/view/default",
+			want: apiEndpoint{Template: "zone/auth/view/{view}", View: "default"},
+		},
+		{
+			name: "unknown",
+			path: "/openapi/dns/other",
+			want: apiEndpoint{Template: "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.parseEndpoint(tt.path); got != tt.want {
+				t.Errorf("parseEndpoint(%s) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
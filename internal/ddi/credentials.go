@@ -0,0 +1,94 @@
+package ddi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// credentials holds the API user and key, read from YAMU_API_USER_FILE and
+// YAMU_API_KEY_FILE if set and from YAMU_API_USER and YAMU_API_KEY otherwise.
+type credentials struct {
+	config *Config
+
+	mu   sync.RWMutex
+	user string
+	key  string
+}
+
+func newCredentials(config *Config) (*credentials, error) {
+	cr := &credentials{config: config}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// files returns the files to watch for changes.
+func (cr *credentials) files() []string {
+	var files []string
+	for _, f := range []string{cr.config.UserFile, cr.config.KeyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// load reads the credentials. On error the previous credentials are kept.
+func (cr *credentials) load() error {
+	user, err := readSecret(cr.config.User, cr.config.UserFile)
+	if err != nil {
+		return fmt.Errorf("YAMU_API_USER: %w", err)
+	}
+	key, err := readSecret(cr.config.Key, cr.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("YAMU_API_KEY: %w", err)
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.user, cr.key = user, key
+
+	return nil
+}
+
+// get returns the current user and key.
+func (cr *credentials) get() (user, key string) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	return cr.user, cr.key
+}
+
+// validateCredentials checks that the user and key are configured.
+func validateCredentials(config *Config) error {
+	if config.User == "" && config.UserFile == "" {
+		return errors.New("YAMU_API_USER or YAMU_API_USER_FILE is required")
+	}
+	if config.Key == "" && config.KeyFile == "" {
+		return errors.New("YAMU_API_KEY or YAMU_API_KEY_FILE is required")
+	}
+
+	return nil
+}
+
+// readSecret returns the content of file without trailing line breaks, or
+// value if file is not set.
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,89 @@
+package ddi
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCredentialsReload(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "user")
+	keyFile := filepath.Join(dir, "key")
+	write := func(name, value string) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(value), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(userFile, "admin\n")
+	write(keyFile, "old-key\n")
+
+	auths := make(chan [2]string, 10)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		user, key, _ := r.BasicAuth()
+		auths <- [2]string{user, key}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	c.UserFile, c.KeyFile = userFile, keyFile
+	creds, err := newCredentials(c.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.creds = creds
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go watchFiles(ctx, time.Millisecond, creds.files(), func() {
+		if err := creds.load(); err == nil {
+			reloaded <- struct{}{}
+		}
+	})
+
+	if _, err := c.ListZones(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-auths; got != [2]string{"admin", "old-key"} {
+		t.Errorf("credentials = %v, want admin, old-key", got)
+	}
+
+	// A size change is noticed even within the mtime resolution
+	write(keyFile, "rotated-key\n")
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("credentials not reloaded")
+	}
+
+	if _, err := c.ListZones(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-auths; got != [2]string{"admin", "rotated-key"} {
+		t.Errorf("credentials = %v, want admin, rotated-key", got)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "env", config: Config{User: "admin", Key: "secret"}},
+		{name: "files", config: Config{UserFile: "/run/secrets/user", KeyFile: "/run/secrets/key"}},
+		{name: "no key", config: Config{User: "admin"}, wantErr: true},
+		{name: "no user", config: Config{KeyFile: "/run/secrets/key"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCredentials(&tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("validateCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ddi
+
+import (
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// changeSet is the set of endpoints ApplyChanges sends to YamuDDI, split by
+// the order in which they are applied.
+type changeSet struct {
+	// deletes are endpoints removed entirely; applied first.
+	deletes []*endpoint.Endpoint
+	// creates are new endpoints and targets added by updates.
+	creates []*endpoint.Endpoint
+	// removes are targets dropped by updates, removed once creates exist.
+	removes []*endpoint.Endpoint
+	// replaceOld and replaceNew hold unchanged targets whose TTL changed.
+	// YamuDDI has no in-place update and identifies records by name, type
+	// and rdata, so these are deleted and recreated last.
+	replaceOld []*endpoint.Endpoint
+	replaceNew []*endpoint.Endpoint
+}
+
+// diffChanges computes the smallest changeSet for changes. Updates are
+// compared per RRset so unchanged targets are left in place.
+func (p *Provider) diffChanges(changes *plan.Changes) *changeSet {
+	cs := &changeSet{
+		deletes: append([]*endpoint.Endpoint{}, changes.Delete...),
+		creates: append([]*endpoint.Endpoint{}, changes.Create...),
+	}
+
+	olds := make(map[endpoint.EndpointKey]*endpoint.Endpoint, len(changes.UpdateOld))
+	for _, ep := range changes.UpdateOld {
+		olds[ep.Key()] = ep
+	}
+
+	for _, newEp := range changes.UpdateNew {
+		oldEp, ok := olds[newEp.Key()]
+		if !ok {
+			cs.creates = append(cs.creates, newEp)
+			continue
+		}
+		delete(olds, newEp.Key())
+
+		if p.endpointView(oldEp) != p.endpointView(newEp) {
+			// Moved to another view, publish the new one before removing the old
+			cs.creates = append(cs.creates, newEp)
+			cs.removes = append(cs.removes, oldEp)
+			continue
+		}
+
+		added, removed, common := diffTargets(oldEp.Targets, newEp.Targets)
+		if len(added) > 0 {
+			cs.creates = append(cs.creates, withTargets(newEp, added))
+		}
+		if len(removed) > 0 {
+			cs.removes = append(cs.removes, withTargets(oldEp, removed))
+		}
+		if len(common) > 0 && oldEp.RecordTTL != newEp.RecordTTL {
+			cs.replaceOld = append(cs.replaceOld, withTargets(oldEp, common))
+			cs.replaceNew = append(cs.replaceNew, withTargets(newEp, common))
+		}
+	}
+
+	for _, ep := range changes.UpdateOld {
+		if _, ok := olds[ep.Key()]; ok {
+			cs.removes = append(cs.removes, ep)
+		}
+	}
+
+	return cs
+}
+
+// diffTargets returns the targets only in newTargets, only in oldTargets and
+// in both.
+func diffTargets(oldTargets, newTargets endpoint.Targets) (added, removed, common []string) {
+	olds := make(map[string]bool, len(oldTargets))
+	for _, t := range oldTargets {
+		olds[t] = true
+	}
+
+	news := make(map[string]bool, len(newTargets))
+	for _, t := range newTargets {
+		news[t] = true
+		if olds[t] {
+			common = append(common, t)
+		} else {
+			added = append(added, t)
+		}
+	}
+
+	for _, t := range oldTargets {
+		if !news[t] {
+			removed = append(removed, t)
+		}
+	}
+
+	return added, removed, common
+}
+
+// withTargets returns a copy of ep with its targets replaced.
+func withTargets(ep *endpoint.Endpoint, targets []string) *endpoint.Endpoint {
+	c := *ep
+	c.Targets = targets
+
+	return &c
+}
@@ -0,0 +1,72 @@
+package ddi
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestDiffChanges(t *testing.T) {
+	dp := &Provider{config: &Config{View: "default"}}
+
+	cs := dp.diffChanges(&plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("a.test.com", "A", 30, "1.1.1.1", "2.2.2.2", "3.3.3.3"),
+			endpoint.NewEndpointWithTTL("b.test.com", "A", 30, "1.1.1.1"),
+			endpoint.NewEndpointWithTTL("c.test.com", "A", 30, "1.1.1.1"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("a.test.com", "A", 30, "1.1.1.1", "2.2.2.2", "4.4.4.4"),
+			endpoint.NewEndpointWithTTL("b.test.com", "A", 60, "1.1.1.1"),
+			endpoint.NewEndpointWithTTL("c.test.com", "A", 30, "1.1.1.1").WithProviderSpecific(providerSpecificView, "intranet"),
+		},
+	})
+
+	targets := func(eps []*endpoint.Endpoint) map[string][]string {
+		m := map[string][]string{}
+		for _, ep := range eps {
+			m[ep.DNSName] = append(m[ep.DNSName], ep.Targets...)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name string
+		got  []*endpoint.Endpoint
+		want map[string][]string
+	}{
+		{
+			name: "creates",
+			got:  cs.creates,
+			want: map[string][]string{"a.test.com": {"4.4.4.4"}, "c.test.com": {"1.1.1.1"}},
+		},
+		{
+			name: "removes",
+			got:  cs.removes,
+			want: map[string][]string{"a.test.com": {"3.3.3.3"}, "c.test.com": {"1.1.1.1"}},
+		},
+		{
+			name: "replaceOld",
+			got:  cs.replaceOld,
+			want: map[string][]string{"b.test.com": {"1.1.1.1"}},
+		},
+		{
+			name: "replaceNew",
+			got:  cs.replaceNew,
+			want: map[string][]string{"b.test.com": {"1.1.1.1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targets(tt.got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffChanges() %s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if cs.replaceNew[0].RecordTTL != 60 {
+		t.Errorf("diffChanges() replaceNew TTL = %v, want 60", cs.replaceNew[0].RecordTTL)
+	}
+}
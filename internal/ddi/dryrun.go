@@ -0,0 +1,53 @@
+package ddi
+
+import (
+	"encoding/json"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PlannedRequest is a mutating request that was not sent in DRY_RUN mode.
+type PlannedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// dryRunLog collects the requests planned by the last ApplyChanges call.
+type dryRunLog struct {
+	mu       sync.Mutex
+	requests []PlannedRequest
+}
+
+// record logs a planned request instead of sending it.
+func (d *dryRunLog) record(method, path string, body []byte) {
+	log.Infof("dry run: %s %s %s", method, path, string(body))
+	ddiDryRunRequests.WithLabelValues(method).Inc()
+
+	req := PlannedRequest{Method: method, Path: path}
+	if json.Valid(body) {
+		req.Body = append(json.RawMessage{}, body...)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.requests = append(d.requests, req)
+}
+
+// reset drops the requests of the previous apply.
+func (d *dryRunLog) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.requests = nil
+}
+
+// list returns the planned requests in the order they were made.
+func (d *dryRunLog) list() []PlannedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]PlannedRequest{}, d.requests...)
+}
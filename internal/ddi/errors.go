@@ -0,0 +1,131 @@
+package ddi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiError describes a failed YamuDDI request: the answer of YamuDDI, if any,
+// and what the request was about. Errors of the client methods are always of
+// this type.
+type apiError struct {
+	// StatusCode is the HTTP status of the answer, 0 if there was none.
+	StatusCode int `json:"status,omitempty"`
+	// RCode is the result code reported by YamuDDI.
+	RCode       int32  `json:"rcode,omitempty"`
+	Description string `json:"description"`
+	// Operation, View, Zone and Record tell what the request was about.
+	Operation string `json:"operation,omitempty"`
+	View      string `json:"view,omitempty"`
+	Zone      string `json:"zone,omitempty"`
+	Record    string `json:"record,omitempty"`
+
+	RetryAfter time.Duration `json:"-"`
+	// Err is the cause of failures without an answer, such as connection
+	// errors.
+	Err error `json:"-"`
+}
+
+func (e *apiError) Error() string {
+	if e.Operation == "" {
+		return e.Description
+	}
+
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	if e.Record != "" {
+		fmt.Fprintf(&sb, " %s", e.Record)
+	}
+	if e.Zone != "" {
+		fmt.Fprintf(&sb, " in zone %s", e.Zone)
+	}
+	if e.View != "" {
+		fmt.Fprintf(&sb, " view %s", e.View)
+	}
+	fmt.Fprintf(&sb, ": %s", e.Description)
+
+	return sb.String()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is temporary, so the same changes
+// may succeed later: YamuDDI was unreachable, overloaded or failed
+// internally. Other failures, such as rejected records, missing permissions
+// or malformed answers, are permanent.
+func (e *apiError) Retryable() bool {
+	if e.StatusCode == 0 {
+		return errors.Is(e.Err, ErrCircuitOpen) || isTransient(e.Err)
+	}
+
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// retryable reports whether err is known to be temporary: its tree holds
+// errors that tell whether they are retryable, and all of them are.
+func retryable(err error) bool {
+	found, ok := false, true
+	var walk func(error)
+	walk = func(err error) {
+		if r, is := err.(interface{ Retryable() bool }); is {
+			found = true
+			ok = ok && r.Retryable()
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if inner := u.Unwrap(); inner != nil {
+				walk(inner)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range u.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	walk(err)
+
+	return found && ok
+}
+
+// permanentError is a failure that sending the request again cannot fix,
+// such as an invalid request path or an answer that is not valid JSON.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// withContext returns err as an apiError describing the request it came
+// from. A single record is named in the error.
+func withContext(err error, op, view, zone string, rrs []*DNSRecord) error {
+	if err == nil {
+		return nil
+	}
+
+	e, ok := err.(*apiError)
+	if ok {
+		c := *e
+		e = &c
+	} else {
+		e = &apiError{Description: err.Error(), Err: err}
+	}
+
+	e.Operation, e.View, e.Zone = op, view, zone
+	if len(rrs) == 1 {
+		e.Record = fmt.Sprintf("%s %s %v", rrs[0].Name, rrs[0].Rtype, rrs[0].Rdata)
+	}
+
+	return e
+}
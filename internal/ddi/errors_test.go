@@ -0,0 +1,65 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *apiError
+		want bool
+	}{
+		{name: "connection error", err: &apiError{Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: true},
+		{name: "circuit open", err: &apiError{Err: ErrCircuitOpen}, want: true},
+		{name: "malformed answer", err: &apiError{Err: &permanentError{errors.New("invalid character")}}, want: false},
+		{name: "too many requests", err: &apiError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "internal error", err: &apiError{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "rejected record", err: &apiError{StatusCode: http.StatusBadRequest, RCode: 1}, want: false},
+		{name: "forbidden", err: &apiError{StatusCode: http.StatusForbidden}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Retryable(); got != tt.want {
+				t.Errorf("Retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateHostOverrideError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"rcode":3,"description":"invalid rdata"}`))
+	})
+
+	err := c.CreateHostOverride(context.Background(), "default", "test.com", &DNSRecord{Name: "www", Rtype: "A", Rdata: "1.1.1.1"})
+
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("CreateHostOverride() error = %v, want an apiError", err)
+	}
+	want := apiError{
+		StatusCode:  http.StatusBadRequest,
+		RCode:       3,
+		Description: "invalid rdata",
+		Operation:   "create records",
+		View:        "default",
+		Zone:        "test.com",
+		Record:      "www A 1.1.1.1",
+	}
+	if *apiErr != want {
+		t.Errorf("CreateHostOverride() error = %+v, want %+v", *apiErr, want)
+	}
+	if apiErr.Retryable() {
+		t.Error("rejected record is retryable")
+	}
+	if got := err.Error(); got != "create records www A 1.1.1.1 in zone test.com view default: invalid rdata" {
+		t.Errorf("Error() = %s", got)
+	}
+}
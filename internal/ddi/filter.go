@@ -0,0 +1,84 @@
+package ddi
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// domainFilterSpec mirrors the JSON form of endpoint.DomainFilter, whose
+// exclusions and regular expressions are not exported.
+type domainFilterSpec struct {
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	RegexInclude string   `json:"regexInclude,omitempty"`
+	RegexExclude string   `json:"regexExclude,omitempty"`
+
+	filter         endpoint.DomainFilter
+	regexInclude   *regexp.Regexp
+	regexExclusion *regexp.Regexp
+}
+
+// newDomainFilterSpec returns the spec of a domain filter.
+func newDomainFilterSpec(df endpoint.DomainFilter) domainFilterSpec {
+	spec := domainFilterSpec{filter: df}
+	if b, err := df.MarshalJSON(); err == nil {
+		_ = json.Unmarshal(b, &spec)
+	}
+
+	// Both expressions were compiled by the domain filter already
+	if spec.RegexInclude != "" {
+		spec.regexInclude = regexp.MustCompile(spec.RegexInclude)
+	}
+	if spec.RegexExclude != "" {
+		spec.regexExclusion = regexp.MustCompile(spec.RegexExclude)
+	}
+
+	return spec
+}
+
+// isRegex reports whether the spec filters by regular expressions.
+func (s domainFilterSpec) isRegex() bool {
+	return s.RegexInclude != "" || s.RegexExclude != ""
+}
+
+// discoverZones reports whether zones are discovered from YamuDDI instead of
+// being listed in the domain filter.
+func (s domainFilterSpec) discoverZones() bool {
+	return len(s.Include) == 0
+}
+
+// matchZone reports whether a discovered zone is selected by the filter.
+// Unlike endpoint.DomainFilter, a regex filter applies both the include and
+// the exclusion expression. The expressions are matched against the zone
+// name without the trailing dot, so `\.example\.com$` does not select the
+// apex example.com while `(^|\.)example\.com$` does.
+func (s domainFilterSpec) matchZone(zone string) bool {
+	if !s.isRegex() {
+		return s.filter.Match(zone)
+	}
+
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	if s.regexInclude != nil && !s.regexInclude.MatchString(zone) {
+		return false
+	}
+
+	return s.regexExclusion == nil || !s.regexExclusion.MatchString(zone)
+}
+
+// withZones returns a domain filter including zones, keeping the exclusions
+// of the spec. A regex filter is returned unchanged, external-dns applies it
+// to endpoint names itself.
+func (s domainFilterSpec) withZones(zones []string) endpoint.DomainFilter {
+	if s.isRegex() {
+		return s.filter
+	}
+
+	zones = append([]string{}, zones...)
+	sort.Strings(zones)
+
+	return endpoint.NewDomainFilterWithExclusions(zones, s.Exclude)
+}
@@ -0,0 +1,77 @@
+package ddi
+
+import (
+	"regexp"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestDomainFilterSpecMatchZone(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter endpoint.DomainFilter
+		zone   string
+		want   bool
+	}{
+		{
+			name:   "regex include",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`\.example\.com$`), regexp.MustCompile("")),
+			zone:   "team.example.com",
+			want:   true,
+		},
+		{
+			name:   "regex not included",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`\.example\.com$`), regexp.MustCompile("")),
+			zone:   "example.org",
+			want:   false,
+		},
+		{
+			name:   "regex apex not included",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`\.example\.com$`), regexp.MustCompile("")),
+			zone:   "example.com.",
+			want:   false,
+		},
+		{
+			name:   "regex apex",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`(^|\.)example\.com$`), regexp.MustCompile("")),
+			zone:   "example.com.",
+			want:   true,
+		},
+		{
+			name:   "regex apex subdomain",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`(^|\.)example\.com$`), regexp.MustCompile("")),
+			zone:   "team.example.com",
+			want:   true,
+		},
+		{
+			name:   "regex excluded",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`\.example\.com$`), regexp.MustCompile(`^internal\.`)),
+			zone:   "internal.example.com",
+			want:   false,
+		},
+		{
+			name:   "regex exclusion keeps include",
+			filter: endpoint.NewRegexDomainFilter(regexp.MustCompile(`\.example\.com$`), regexp.MustCompile(`^internal\.`)),
+			zone:   "example.org",
+			want:   false,
+		},
+		{
+			name:   "exclude list",
+			filter: endpoint.NewDomainFilterWithExclusions(nil, []string{"internal.example.com"}),
+			zone:   "internal.example.com.",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := newDomainFilterSpec(tt.filter)
+			if !spec.discoverZones() {
+				t.Fatalf("discoverZones() = false, want true")
+			}
+			if got := spec.matchZone(tt.zone); got != tt.want {
+				t.Errorf("matchZone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// journal records the mutations made during a single ApplyChanges call so
+// they can be undone when a later step fails. Created zones are only
+// reported, never deleted.
+type journal struct {
+	client  *httpClient
+	deleted map[ZoneKey][]*DNSRecord
+	created map[ZoneKey][]*DNSRecord
+	zones   []ZoneKey
+}
+
+func newJournal(client *httpClient) *journal {
+	return &journal{
+		client:  client,
+		deleted: map[ZoneKey][]*DNSRecord{},
+		created: map[ZoneKey][]*DNSRecord{},
+	}
+}
+
+// recordDeleted notes records that were deleted successfully.
+func (j *journal) recordDeleted(zk ZoneKey, rrs []*DNSRecord) {
+	j.deleted[zk] = append(j.deleted[zk], rrs...)
+	if !j.client.DryRun {
+		countRecords(recordsDeleted, zk, rrs)
+	}
+}
+
+// recordCreated notes records that were created successfully.
+func (j *journal) recordCreated(zk ZoneKey, rrs []*DNSRecord) {
+	j.created[zk] = append(j.created[zk], rrs...)
+	if !j.client.DryRun {
+		countRecords(recordsCreated, zk, rrs)
+	}
+}
+
+// zoneCreated notes a zone that was created.
+func (j *journal) zoneCreated(zk ZoneKey) {
+	j.zones = append(j.zones, zk)
+}
+
+// rollback undoes every recorded mutation, removing created records first and
+// then restoring deleted ones. It returns a RollbackError wrapping cause.
+func (j *journal) rollback(ctx context.Context, cause error) error {
+	// Roll back even if the caller gave up on the apply
+	ctx = context.WithoutCancel(ctx)
+
+	rbErr := &RollbackError{Err: cause, CreatedZones: j.zones}
+
+	var errs []error
+	for zk, rrs := range j.created {
+		if err := j.client.DeleteHostOverrideBulk(ctx, zk.View, zk.Zone, rrs); err != nil {
+			errs = append(errs, fmt.Errorf("remove created records: %w", err))
+			continue
+		}
+		if !j.client.DryRun {
+			countRecords(recordsDeleted, zk, rrs)
+		}
+		rbErr.Removed += len(rrs)
+	}
+
+	for zk, rrs := range j.deleted {
+		if err := j.client.CreateHostOverrideBulk(ctx, zk.View, zk.Zone, rrs); err != nil {
+			errs = append(errs, fmt.Errorf("restore deleted records: %w", err))
+			continue
+		}
+		if !j.client.DryRun {
+			countRecords(recordsCreated, zk, rrs)
+		}
+		rbErr.Restored += len(rrs)
+	}
+
+	rbErr.RollbackErr = errors.Join(errs...)
+	log.Warnf("apply: %v", rbErr)
+
+	return rbErr
+}
+
+// RollbackError is returned by ApplyChanges when applying failed and the
+// changes made so far were rolled back.
+type RollbackError struct {
+	// Err is the error that caused the rollback.
+	Err error `json:"-"`
+	// Removed is the number of created records that were removed again.
+	Removed int `json:"removed"`
+	// Restored is the number of deleted records that were created again.
+	Restored int `json:"restored"`
+	// CreatedZones are the zones created before the failure. They are kept.
+	CreatedZones []ZoneKey `json:"createdZones,omitempty"`
+	// RollbackErr holds the errors of rollback steps that failed.
+	RollbackErr error `json:"-"`
+}
+
+func (e *RollbackError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "apply failed: %v; rolled back: removed %d created records, restored %d deleted records",
+		e.Err, e.Removed, e.Restored)
+	for i, zk := range e.CreatedZones {
+		if i == 0 {
+			sb.WriteString("; kept created zones: ")
+		} else {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s in view %s", zk.Zone, zk.View)
+	}
+	if e.RollbackErr != nil {
+		fmt.Fprintf(&sb, "; rollback incomplete: %v", e.RollbackErr)
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the cause and the errors of failed rollback steps.
+func (e *RollbackError) Unwrap() []error {
+	return []error{e.Err, e.RollbackErr}
+}
+
+// Retryable reports whether the failure that caused the rollback is
+// temporary. Rolling back does not change that.
+func (e *RollbackError) Retryable() bool {
+	return retryable(e.Err)
+}
@@ -0,0 +1,105 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestJournalRollback(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+	})
+
+	zk := ZoneKey{View: "default", Zone: "test.com"}
+	j := newJournal(c)
+	j.recordDeleted(zk, []*DNSRecord{addRRs["testA"]})
+	j.recordCreated(zk, []*DNSRecord{addRRs["testAAAA"], addRRs["testCNAME"]})
+	j.zoneCreated(zk)
+
+	cause := errors.New("boom")
+	err := j.rollback(context.Background(), cause)
+
+	var rbErr *RollbackError
+	if !errors.As(err, &rbErr) {
+		t.Fatalf("rollback() = %v, want *RollbackError", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("rollback() does not wrap cause")
+	}
+	if rbErr.Removed != 2 || rbErr.Restored != 1 || rbErr.RollbackErr != nil {
+		t.Errorf("rollback() = %+v, want 2 removed, 1 restored", rbErr)
+	}
+	if !strings.Contains(err.Error(), "kept created zones: test.com in view default") {
+		t.Errorf("rollback() error = %v, want the created zone", err)
+	}
+
+	want := []string{
+		http.MethodDelete + " /openapi/dns/zone/auth/rr/view/default/zone/test.com",
+		http.MethodPost + " /openapi/dns/zone/auth/rr/view/default/zone/test.com",
+	}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("rollback() calls = %v, want %v", calls, want)
+	}
+}
+
+func TestJournalRollbackDryRun(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%s %s sent in dry run mode", r.Method, r.URL.Path)
+	})
+	c.DryRun = true
+
+	zk := ZoneKey{View: "default", Zone: "dryrun.test"}
+	j := newJournal(c)
+	j.recordDeleted(zk, []*DNSRecord{addRRs["testA"]})
+	j.recordCreated(zk, []*DNSRecord{addRRs["testAAAA"]})
+
+	_ = j.rollback(context.Background(), errors.New("boom"))
+
+	if v := testutil.ToFloat64(recordsDeleted.WithLabelValues(zk.View, zk.Zone, "AAAA")); v != 0 {
+		t.Errorf("rollback() in dry run counted %v deleted records", v)
+	}
+	if v := testutil.ToFloat64(recordsCreated.WithLabelValues(zk.View, zk.Zone, "A")); v != 0 {
+		t.Errorf("rollback() in dry run counted %v created records", v)
+	}
+}
+
+func TestRollbackErrorRetryable(t *testing.T) {
+	outage := &apiError{StatusCode: http.StatusServiceUnavailable}
+	rejected := &apiError{StatusCode: http.StatusBadRequest, RCode: 3}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "unclassified", err: errors.New("boom"), want: false},
+		{name: "retryable", err: fmt.Errorf("chunk 1/1: %w", outage), want: true},
+		{name: "permanent", err: fmt.Errorf("chunk 1/1: %w", rejected), want: false},
+		{
+			name: "joined",
+			err:  errors.Join(fmt.Errorf("chunk 1/2: %w", outage), fmt.Errorf("chunk 2/2: %w", rejected)),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rbErr := &RollbackError{Err: tt.err, RollbackErr: outage}
+			if got := rbErr.Retryable(); got != tt.want {
+				t.Errorf("Retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package ddi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// requestLimiter bounds the rate and the concurrency of requests to YamuDDI.
+type requestLimiter struct {
+	kind  string
+	rate  *rate.Limiter
+	slots chan struct{}
+}
+
+// newRequestLimiter returns a limiter allowing rps requests per second with
+// the given burst, and at most concurrency requests in flight. A rate or
+// concurrency of 0 means no limit. A burst below 1 defaults to the rate.
+func newRequestLimiter(kind string, rps float64, burst, concurrency int) *requestLimiter {
+	l := &requestLimiter{kind: kind}
+	if rps > 0 {
+		if burst < 1 {
+			burst = int(math.Max(1, math.Ceil(rps)))
+		}
+		l.rate = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+	if concurrency > 0 {
+		l.slots = make(chan struct{}, concurrency)
+	}
+
+	return l
+}
+
+// acquire waits until a request may be sent. The returned function must be
+// called once the request is done.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	defer func() {
+		ddiLimiterWait.WithLabelValues(l.kind).Observe(time.Since(start).Seconds())
+	}()
+
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-l.slots }
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// limiter returns the limiter for requests of the method: writes have their
+// own budget if YAMU_WRITE_RATE_LIMIT or YAMU_WRITE_MAX_CONCURRENCY is set.
+func (c *httpClient) limiter(method string) *requestLimiter {
+	if method == http.MethodGet || method == http.MethodHead || c.writeLimiter == nil {
+		return c.readLimiter
+	}
+
+	return c.writeLimiter
+}
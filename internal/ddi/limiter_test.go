@@ -0,0 +1,86 @@
+package ddi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiterConcurrency(t *testing.T) {
+	l := newRequestLimiter("all", 0, 0, 1)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); err == nil {
+		t.Fatal("acquire() beyond the concurrency cap succeeded")
+	}
+
+	release()
+	release, err = l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() after release = %v", err)
+	}
+	release()
+}
+
+func TestRequestLimiterRate(t *testing.T) {
+	l := newRequestLimiter("all", 1, 1, 0)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); err == nil {
+		t.Fatal("acquire() beyond the rate succeeded")
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		method    string
+		wantWrite bool
+	}{
+		{
+			name:   "shared budget",
+			config: Config{Host: "http://127.0.0.1", RateLimit: 10},
+			method: http.MethodPost,
+		},
+		{
+			name:   "read with separate budgets",
+			config: Config{Host: "http://127.0.0.1", WriteRateLimit: 5},
+			method: http.MethodGet,
+		},
+		{
+			name:      "write with separate budgets",
+			config:    Config{Host: "http://127.0.0.1", WriteMaxConcurrency: 2},
+			method:    http.MethodDelete,
+			wantWrite: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newYamuDDIClient(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := c.limiter(tt.method)
+			if (got == c.writeLimiter && got != nil) != tt.wantWrite {
+				t.Errorf("limiter(%s) = %s, wantWrite %v", tt.method, got.kind, tt.wantWrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "yamu_ddi"
+
+var (
+	ddiRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of DDI API requests sent, by result code.",
+	}, []string{"method", "endpoint", "code"})
+
+	ddiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of DDI API requests, by result code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint", "code"})
+
+	ddiRequestRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_retries_total",
+		Help:      "Number of DDI API requests retried after a transient failure.",
+	}, []string{"method"})
+
+	ddiRequestFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_failures_total",
+		Help:      "Number of DDI API requests that failed after all retries.",
+	}, []string{"method"})
+
+	ddiCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "circuit_breaker_state",
+		Help:      "State of the DDI circuit breaker: 0 closed, 1 half-open, 2 open.",
+	})
+
+	ddiActiveNode = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_node",
+		Help:      "1 for the DDI node requests are sent to, 0 for the others.",
+	}, []string{"node"})
+
+	ddiNodeUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_up",
+		Help:      "Whether the DDI node passed its last health check or request.",
+	}, []string{"node"})
+
+	ddiLimiterWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "limiter_wait_seconds",
+		Help:      "Time DDI API requests waited for the client-side rate and concurrency limits.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"kind"})
+
+	ddiDryRunRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dry_run_requests_total",
+		Help:      "Number of mutating DDI API requests recorded instead of sent in dry run mode.",
+	}, []string{"method"})
+
+	recordsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "records_created_total",
+		Help:      "Number of DNS records created in DDI.",
+	}, []string{"view", "zone", "type"})
+
+	recordsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "records_deleted_total",
+		Help:      "Number of DNS records deleted from DDI.",
+	}, []string{"view", "zone", "type"})
+
+	managedRecords = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "managed_records",
+		Help:      "Number of DNS records owned by the webhook, as of the last full read.",
+	}, []string{"view", "zone"})
+
+	endpointsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "endpoints_skipped_total",
+		Help:      "Number of endpoints or targets not written to DDI, by reason.",
+	}, []string{"reason"})
+)
+
+// Reasons for skipping endpoints in convertDnsRecord and PTR records in
+// ptrPlanner.
+const (
+	skipUnsupportedType = "unsupported_type"
+	skipUnknownView     = "unknown_view"
+	skipNoZone          = "no_zone"
+	skipInvalidTarget   = "invalid_target"
+	skipPTROtherOwner   = "ptr_other_owner"
+)
+
+// observeRequest records the result and latency of a DDI API request.
+func observeRequest(ctx context.Context, method, endpoint string, start time.Time, err error) {
+	code := "200"
+	var apiErr *apiError
+	switch {
+	case err == nil:
+	case errors.As(err, &apiErr):
+		code = strconv.Itoa(apiErr.StatusCode)
+	case ctx.Err() != nil:
+		code = "canceled"
+	default:
+		code = "error"
+	}
+
+	ddiRequests.WithLabelValues(method, endpoint, code).Inc()
+	ddiRequestDuration.WithLabelValues(method, endpoint, code).Observe(time.Since(start).Seconds())
+}
+
+// countRecords adds the records to counter, per zone and type.
+func countRecords(counter *prometheus.CounterVec, zk ZoneKey, rrs []*DNSRecord) {
+	for _, rr := range rrs {
+		counter.WithLabelValues(zk.View, zk.Zone, rr.Rtype).Inc()
+	}
+}
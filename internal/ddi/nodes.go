@@ -0,0 +1,211 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// node is one management address of YamuDDI.
+type node struct {
+	url *url.URL
+	// name identifies the node in logs and metrics, without credentials.
+	name    string
+	healthy bool
+}
+
+// nodePool tracks the YamuDDI nodes of YAMU_HOST and the one in use.
+// Requests go to the active node; when it fails, the pool fails over to the
+// next healthy node. A failed node is used again once a health check passes
+// and another node fails.
+type nodePool struct {
+	mu     sync.Mutex
+	nodes  []*node
+	active int
+}
+
+// pinKey marks a context whose requests must all go to one node.
+type pinKey struct{}
+
+// newNodePool parses the comma separated hosts. All hosts must use the same
+// path.
+func newNodePool(hosts string) (*nodePool, error) {
+	pool := &nodePool{}
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		u.Path = path.Join(u.Path, apiDnsPrefix)
+		if len(pool.nodes) > 0 && u.Path != pool.nodes[0].url.Path {
+			return nil, fmt.Errorf("YAMU_HOST: %s has a different path than %s", host, pool.nodes[0].name)
+		}
+
+		pool.nodes = append(pool.nodes, &node{
+			url:     u,
+			name:    (&url.URL{Scheme: u.Scheme, Host: u.Host}).String(),
+			healthy: true,
+		})
+	}
+	if len(pool.nodes) == 0 {
+		return nil, errors.New("YAMU_HOST: no host")
+	}
+
+	pool.setActive(0)
+
+	return pool, nil
+}
+
+// pin returns a context whose requests all go to the node active now, so the
+// writes of one apply are not spread over several nodes.
+func (np *nodePool) pin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pinKey{}, np.current())
+}
+
+// pick returns the node for a request made with ctx.
+func (np *nodePool) pick(ctx context.Context) *node {
+	if n, ok := ctx.Value(pinKey{}).(*node); ok {
+		return n
+	}
+
+	return np.current()
+}
+
+func (np *nodePool) current() *node {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+
+	return np.nodes[np.active]
+}
+
+// fail marks n as down. If n is active, the next healthy node becomes active,
+// or simply the next node if none is known to be healthy.
+func (np *nodePool) fail(n *node) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+
+	n.healthy = false
+	ddiNodeUp.WithLabelValues(n.name).Set(0)
+	if np.nodes[np.active] != n || len(np.nodes) == 1 {
+		return
+	}
+
+	next := (np.active + 1) % len(np.nodes)
+	for i := 1; i < len(np.nodes); i++ {
+		idx := (np.active + i) % len(np.nodes)
+		if np.nodes[idx].healthy {
+			next = idx
+			break
+		}
+	}
+
+	log.Warnf("nodes: %s failed, failing over to %s", n.name, np.nodes[next].name)
+	np.setActive(next)
+}
+
+// setActive must be called with mu held or before the pool is shared.
+func (np *nodePool) setActive(idx int) {
+	np.active = idx
+	for i, n := range np.nodes {
+		v := 0.0
+		if i == idx {
+			v = 1
+		}
+		ddiActiveNode.WithLabelValues(n.name).Set(v)
+	}
+}
+
+// setHealthy records the result of a health check of n.
+func (np *nodePool) setHealthy(n *node, healthy bool) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+
+	if n.healthy != healthy {
+		log.Infof("nodes: health check of %s: healthy %v", n.name, healthy)
+	}
+	n.healthy = healthy
+	v := 0.0
+	if healthy {
+		v = 1
+	}
+	ddiNodeUp.WithLabelValues(n.name).Set(v)
+}
+
+// isNodeDown reports whether err means the node did not serve the request,
+// as opposed to rejecting it.
+func isNodeDown(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	return isTransient(err)
+}
+
+// healthCheck probes every node each interval until ctx is done. A node is
+// healthy if it answers the zone list of the default view without a server
+// error.
+func (c *httpClient) healthCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, n := range c.nodes.nodes {
+			c.nodes.setHealthy(n, c.probe(ctx, n) == nil)
+		}
+	}
+}
+
+// probe sends a health check request to n.
+func (c *httpClient) probe(ctx context.Context, n *node) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.OpenAPITimeout)*time.Second)
+	defer cancel()
+
+	u := n.url.JoinPath(fmt.Sprintf(apiZoneList, c.View))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		c.logUnknownAuthority(err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return nil
+}
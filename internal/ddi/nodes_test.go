@@ -0,0 +1,74 @@
+package ddi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNodeFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"name":"test.com"}]}`))
+	}))
+	t.Cleanup(up.Close)
+
+	c, err := newYamuDDIClient(&Config{
+		Host:                down.URL + ", " + up.URL,
+		OpenAPITimeout:      5,
+		RetryMax:            1,
+		RetryInitialBackoff: time.Millisecond,
+		RetryMaxBackoff:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A pinned request stays on the failing node
+	pinned := c.nodes.pin(context.Background())
+	if _, err := c.ListZones(pinned, "default"); err == nil {
+		t.Error("ListZones() on the pinned failing node succeeded")
+	}
+	if got := c.nodes.current().name; got != up.URL {
+		t.Fatalf("active node = %s, want %s", got, up.URL)
+	}
+
+	zones, err := c.ListZones(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("ListZones() after failover error = %v", err)
+	}
+	if len(zones) != 1 {
+		t.Errorf("ListZones() = %v, want test.com", zones)
+	}
+}
+
+func TestNewNodePool(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   string
+		want    int
+		wantErr bool
+	}{
+		{name: "single", hosts: "https://10.0.0.1", want: 1},
+		{name: "pair", hosts: "https://10.0.0.1,https://10.0.0.2", want: 2},
+		{name: "different paths", hosts: "https://10.0.0.1/a,https://10.0.0.2/b", wantErr: true},
+		{name: "empty", hosts: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := newNodePool(tt.hosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newNodePool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(pool.nodes) != tt.want {
+				t.Errorf("newNodePool() has %d nodes, want %d", len(pool.nodes), tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,17 @@ package ddi
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/arrays"
 	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/domain"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
@@ -18,32 +22,49 @@ import (
 type Provider struct {
 	provider.BaseProvider
 
-	client               *httpClient
-	domainFilter         endpoint.DomainFilter
-	domainFilterDDIRWMux sync.RWMutex
-	domainFilterDDI      []string
-	config               *Config
+	client                 *httpClient
+	domainFilter           endpoint.DomainFilter
+	domainFilterSpec       domainFilterSpec
+	domainFilterDDIRWMux   sync.RWMutex
+	domainFilterDDI        map[string][]string
+	domainFilterDDIUpdated time.Time
+	zoneCache              *zoneCache
+	recordCache            *recordCache
+	config                 *Config
 }
 
 var (
-	source          = "external-dns-yamu"
 	strategyInherit = "inherit"
 	strategyRewrite = "rewrite"
-	supportTypes    = []string{"A", "AAAA", "CNAME"}
+	supportTypes    = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV", "NS", "CAA"}
 )
 
+// providerSpecificView selects the view an endpoint is published in.
+const providerSpecificView = "webhook/yamu-view"
+
 // NewYamuDDIProvider initializes a new DNSProvider.
 func NewYamuDDIProvider(domainFilter endpoint.DomainFilter, config *Config) (provider.Provider, error) {
+	if err := validateCredentials(config); err != nil {
+		return nil, fmt.Errorf("provider: %w", err)
+	}
+
 	c, err := newYamuDDIClient(config)
 
 	if err != nil {
 		return nil, fmt.Errorf("provider: failed to create the YamuDDI client: %w", err)
 	}
 
+	if err := validateZoneCreation(config); err != nil {
+		return nil, fmt.Errorf("provider: %w", err)
+	}
+
 	p := &Provider{
-		client:       c,
-		domainFilter: domainFilter,
-		config:       config,
+		client:           c,
+		domainFilter:     domainFilter,
+		domainFilterSpec: newDomainFilterSpec(domainFilter),
+		zoneCache:        newZoneCache(config.ZoneCacheTTL, config.ZoneCacheNegativeTTL),
+		recordCache:      newRecordCache(config.RecordsCacheMaxAge),
+		config:           config,
 	}
 
 	return p, nil
@@ -54,44 +75,117 @@ type EndpointKey struct {
 	RecordType string
 }
 
+// ZoneKey identifies a zone in a view.
+type ZoneKey struct {
+	View string `json:"view"`
+	Zone string `json:"zone"`
+}
+
 // Records returns the list of HostOverride records in YamuDDI Unbound.
 func (p *Provider) Records(ctx context.Context) (endpoints []*endpoint.Endpoint, err error) {
+	if eps, ok := p.recordCache.get(); ok {
+		log.Debugf("records: %d endpoints from cache", len(eps))
+		return eps, nil
+	}
+
+	if err := p.setDDIDomainFilter(ctx); err != nil {
+		return nil, err
+	}
+	zks := p.ddiZones()
+	results := make([][]*endpoint.Endpoint, len(zks))
+	owned := make([]int, len(zks))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.zoneConcurrency())
+	for i, zk := range zks {
+		g.Go(func() error {
+			eps, n, err := p.zoneRecords(gctx, zk)
+			results[i], owned[i] = eps, n
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-	p.setDDIDomainFilter()
+	// Only a complete read replaces the counts, dropping zones that are no
+	// longer managed
+	managedRecords.Reset()
+	for i, zk := range zks {
+		managedRecords.WithLabelValues(zk.View, zk.Zone).Set(float64(owned[i]))
+	}
+
+	// Zones are in a fixed order, so the result does not depend on timing
 	endpoints = make([]*endpoint.Endpoint, 0)
-	for _, zone := range p.getDDIDomainFilter() {
-		records, err := p.client.GetHostOverrides(zone)
-		if err != nil {
-			return nil, err
-		}
-
-		epMap := map[EndpointKey]*endpoint.Endpoint{}
-		for _, record := range records {
-			dnsName := domain.HostAddDomain(record.Name, zone)
-			if _, ok := epMap[EndpointKey{dnsName, record.Rtype}]; !ok {
-				epMap[EndpointKey{dnsName, record.Rtype}] = &endpoint.Endpoint{
-					DNSName:    dnsName,
-					RecordType: record.Rtype,
-					RecordTTL:  endpoint.TTL(record.TTL),
-				}
-			}
+	for _, eps := range results {
+		endpoints = append(endpoints, eps...)
+	}
+
+	log.Infof("records: retrieving: %+v", endpoints)
+	p.recordCache.set(p.cacheEntries(endpoints))
+
+	return endpoints, nil
+}
+
+// zoneRecords returns the endpoints of the records owned by the webhook in
+// the zone, sorted by name and type, and the number of owned records.
+func (p *Provider) zoneRecords(ctx context.Context, zk ZoneKey) ([]*endpoint.Endpoint, int, error) {
+	records, err := p.client.GetHostOverrides(ctx, zk.View, zk.Zone)
+	if err != nil {
+		return nil, 0, err
+	}
 
-			rdata := fmt.Sprintf("%v", record.Rdata)
-			if record.Rtype == "CNAME" {
-				rdata = strings.TrimSuffix(rdata, ".")
+	owned := 0
+	epMap := map[EndpointKey]*endpoint.Endpoint{}
+	for _, record := range records {
+		if !p.config.owns(record.Source) {
+			continue
+		}
+		owned++
+
+		dnsName := domain.HostAddDomain(record.Name, zk.Zone)
+		if _, ok := epMap[EndpointKey{dnsName, record.Rtype}]; !ok {
+			ep := &endpoint.Endpoint{
+				DNSName:    dnsName,
+				RecordType: record.Rtype,
+				RecordTTL:  endpoint.TTL(record.TTL),
 			}
-			epMap[EndpointKey{dnsName, record.Rtype}].Targets = append(
-				epMap[EndpointKey{dnsName, record.Rtype}].Targets, rdata)
+			if zk.View != p.config.View {
+				ep.WithProviderSpecific(providerSpecificView, zk.View)
+			}
+			epMap[EndpointKey{dnsName, record.Rtype}] = ep
 		}
 
-		for _, ep := range epMap {
-			endpoints = append(endpoints, ep)
+		rdata, err := rdataToTarget(record.Rtype, record.Rdata)
+		if err != nil {
+			log.Warnf("records: skip %s %s: %v", dnsName, record.Rtype, err)
+			continue
 		}
+		epMap[EndpointKey{dnsName, record.Rtype}].Targets = append(
+			epMap[EndpointKey{dnsName, record.Rtype}].Targets, rdata)
 	}
 
-	log.Infof("records: retrieving: %+v", endpoints)
+	endpoints := make([]*endpoint.Endpoint, 0, len(epMap))
+	for _, ep := range epMap {
+		endpoints = append(endpoints, ep)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].DNSName != endpoints[j].DNSName {
+			return endpoints[i].DNSName < endpoints[j].DNSName
+		}
+		return endpoints[i].RecordType < endpoints[j].RecordType
+	})
 
-	return endpoints, nil
+	return endpoints, owned, nil
+}
+
+// zoneConcurrency returns the number of zones read from YamuDDI at once.
+func (p *Provider) zoneConcurrency() int {
+	if p.config.ZoneConcurrency < 1 {
+		return 1
+	}
+
+	return p.config.ZoneConcurrency
 }
 
 // AdjustEndpoints adjusts the endpoints to the provider's requirements.
@@ -101,6 +195,16 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 		if !ep.RecordTTL.IsConfigured() {
 			ep.RecordTTL = endpoint.TTL(p.client.DefaultTTL)
 		}
+
+		// Normalize targets to the form returned by Records
+		for i, target := range ep.Targets {
+			ep.Targets[i] = canonicalTarget(ep.RecordType, target)
+		}
+
+		// The default view is implied, Records does not report it
+		if view, ok := ep.GetProviderSpecificProperty(providerSpecificView); ok && view == p.config.View {
+			ep.DeleteProviderSpecificProperty(providerSpecificView)
+		}
 	}
 
 	return endpoints, nil
@@ -108,90 +212,467 @@ func (p *Provider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.
 
 // ApplyChanges applies a given set of changes in the DNS provider.
 func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	// Keep all writes of one apply, and their rollback, on one node
+	ctx = p.client.nodes.pin(ctx)
+	if err := p.applyChanges(ctx, changes); err != nil {
+		// Part of the changes may have been applied
+		p.recordCache.invalidate()
+		return err
+	}
+
+	// Nothing changed in dry run mode
+	if p.recordCache.enabled() && !p.config.DryRun {
+		removed := append(append([]*endpoint.Endpoint{}, changes.Delete...), changes.UpdateOld...)
+		added := append(append([]*endpoint.Endpoint{}, changes.Create...), changes.UpdateNew...)
+		p.recordCache.update(p.cacheEntries(removed), p.cacheEntries(added))
+	}
+
+	return nil
+}
+
+func (p *Provider) applyChanges(ctx context.Context, changes *plan.Changes) error {
 	log.Infof("apply: changes: %+v", changes)
-	p.setDDIDomainFilter()
+	if p.config.DryRun {
+		p.client.dryRun.reset()
+	}
+	if err := p.setDDIDomainFilter(ctx); err != nil {
+		return err
+	}
 
-	dels := append(changes.UpdateOld, changes.Delete...)
-	dsD, err := p.convertDnsRecord(dels)
+	j := newJournal(p.client)
+	cs := p.diffChanges(changes)
+	if p.config.CreateZones {
+		if err := p.ensureZones(ctx, j, cs.creates); err != nil {
+			return j.rollback(ctx, err)
+		}
+	}
+
+	dsDel, err := p.convertDnsRecord(cs.deletes)
 	if err != nil {
-		return err
+		return j.rollback(ctx, err)
+	}
+	dsAdd, err := p.convertDnsRecord(cs.creates)
+	if err != nil {
+		return j.rollback(ctx, err)
+	}
+	dsRm, err := p.convertDnsRecord(cs.removes)
+	if err != nil {
+		return j.rollback(ctx, err)
+	}
+	dsRepOld, err := p.convertDnsRecord(cs.replaceOld)
+	if err != nil {
+		return j.rollback(ctx, err)
+	}
+	dsRepNew, err := p.convertDnsRecord(cs.replaceNew)
+	if err != nil {
+		return j.rollback(ctx, err)
 	}
 
-	for zone, rrs := range dsD {
-		if err := p.client.DeleteHostOverrideBulk(zone, rrs); err != nil {
+	if p.config.CreatePTR {
+		// PTRs have no ordering constraints, so they go with the first steps
+		ptrDels, ptrAdds, err := p.ptrRecords(
+			ctx,
+			mergeRecords(dsDel, dsRm, dsRepOld),
+			mergeRecords(dsAdd, dsRepNew),
+		)
+		if err != nil {
+			return j.rollback(ctx, err)
+		}
+		dsDel = mergeRecords(dsDel, ptrDels)
+		dsAdd = mergeRecords(dsAdd, ptrAdds)
+	}
+
+	// Create before delete so updated names never go missing. Records whose
+	// TTL changed cannot exist twice, so they are replaced last; if the
+	// create fails, the rollback restores them.
+	steps := []func() error{
+		func() error { return p.deleteRecords(ctx, j, dsDel) },
+		func() error { return p.createRecords(ctx, j, dsAdd) },
+		func() error { return p.deleteRecords(ctx, j, dsRm) },
+		func() error { return p.deleteRecords(ctx, j, dsRepOld) },
+		func() error { return p.createRecords(ctx, j, dsRepNew) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return j.rollback(ctx, err)
+		}
+	}
+	log.Infof("apply: changes applied")
+	return nil
+}
+
+// deleteRecords deletes the records of every zone, one request per zone.
+// Records not owned by this webhook are skipped.
+func (p *Provider) deleteRecords(ctx context.Context, j *journal, ds map[ZoneKey][]*DNSRecord) error {
+	for zk, rrs := range ds {
+		rrs, err := p.filterOwned(ctx, zk, rrs)
+		if err != nil {
 			return err
 		}
+		if len(rrs) == 0 {
+			continue
+		}
+
+		if err := p.client.DeleteHostOverrideBulk(ctx, zk.View, zk.Zone, rrs); err != nil {
+			return err
+		}
+		j.recordDeleted(zk, rrs)
 	}
 
-	creates := append(changes.Create, changes.UpdateNew...)
-	dsA, err := p.convertDnsRecord(creates)
+	return nil
+}
+
+// filterOwned returns the records of rrs that exist in the zone with the
+// owner ID of this webhook.
+func (p *Provider) filterOwned(ctx context.Context, zk ZoneKey, rrs []*DNSRecord) ([]*DNSRecord, error) {
+	if len(rrs) == 0 {
+		return rrs, nil
+	}
+
+	existing, err := p.client.GetHostOverrides(ctx, zk.View, zk.Zone)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	owned := make(map[string]bool, len(existing))
+	for _, rr := range existing {
+		if p.config.owns(rr.Source) {
+			owned[recordKey(rr)] = true
+		}
+	}
+
+	res := make([]*DNSRecord, 0, len(rrs))
+	for _, rr := range rrs {
+		if !owned[recordKey(rr)] {
+			log.Warnf("apply: %s %s %v in zone %s view %s is not owned by %q, skipped",
+				rr.Name, rr.Rtype, rr.Rdata, zk.Zone, zk.View, p.config.owner())
+			continue
+		}
+		res = append(res, rr)
+	}
+
+	return res, nil
+}
+
+// recordKey identifies a record by name, type, canonical target and TTL, so a
+// copy of a record with another TTL is a different record. Records without
+// the rewrite strategy inherit the zone TTL whatever TTL they report.
+func recordKey(rr *DNSRecord) string {
+	target, err := rdataToTarget(rr.Rtype, rr.Rdata)
+	if err != nil {
+		target = fmt.Sprintf("%v", rr.Rdata)
+	}
+
+	ttl := strategyInherit
+	if rr.TTLStrategy == strategyRewrite {
+		ttl = fmt.Sprintf("%s %d", strategyRewrite, rr.TTL)
 	}
 
-	for zone, rrs := range dsA {
-		for _, rr := range rrs {
-			if err := p.client.CreateHostOverride(zone, rr); err != nil {
-				return err
+	return strings.ToLower(fmt.Sprintf("%s/%s/%s/%s", rr.Name, rr.Rtype, target, ttl))
+}
+
+// createRecords creates the records of every zone in chunks of
+// CreateBatchSize, one request per chunk. A failed chunk does not stop the
+// remaining ones; all chunk errors are returned together.
+func (p *Provider) createRecords(ctx context.Context, j *journal, ds map[ZoneKey][]*DNSRecord) error {
+	var errs []error
+	for zk, rrs := range ds {
+		if len(rrs) == 0 {
+			continue
+		}
+
+		chunks := arrays.Chunk(rrs, p.config.CreateBatchSize)
+		for i, chunk := range chunks {
+			if err := p.client.CreateHostOverrideBulk(ctx, zk.View, zk.Zone, chunk); err != nil {
+				log.Errorf("apply: create chunk %d/%d of zone %s in view %s (%d records) failed: %v",
+					i+1, len(chunks), zk.Zone, zk.View, len(chunk), err)
+				errs = append(errs, fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err))
+				continue
 			}
+			j.recordCreated(zk, chunk)
 		}
 	}
-	log.Infof("apply: changes applied")
-	return nil
+
+	return errors.Join(errs...)
+}
+
+// ptrRecords returns the PTR records to delete and create for the A/AAAA
+// records of dels and adds, keyed by reverse zone.
+func (p *Provider) ptrRecords(ctx context.Context, dels, adds map[ZoneKey][]*DNSRecord) (map[ZoneKey][]*DNSRecord, map[ZoneKey][]*DNSRecord, error) {
+	pl := newPTRPlanner(p.client, p.zoneExist)
+
+	ptrDels, err := pl.deletes(ctx, dels)
+	if err != nil {
+		return nil, nil, err
+	}
+	ptrAdds, err := pl.creates(ctx, adds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(pl.skipped) > 0 {
+		log.Warnf("apply: skipped PTR records owned by others: %v", pl.skipped)
+	}
+
+	return ptrDels, ptrAdds, nil
 }
 
-// setDomainDDIFilter
-func (p *Provider) setDDIDomainFilter() {
+// mergeRecords merges record maps into a new one.
+func mergeRecords(ds ...map[ZoneKey][]*DNSRecord) map[ZoneKey][]*DNSRecord {
+	rd := make(map[ZoneKey][]*DNSRecord)
+	for _, d := range ds {
+		for zk, rrs := range d {
+			rd[zk] = append(rd[zk], rrs...)
+		}
+	}
+
+	return rd
+}
+
+// setDDIDomainFilter refreshes the zones of every view that exist in YamuDDI,
+// at most once per ZoneRefreshInterval. On error the previous zones are kept.
+func (p *Provider) setDDIDomainFilter(ctx context.Context) error {
 	p.domainFilterDDIRWMux.Lock()
 	defer p.domainFilterDDIRWMux.Unlock()
 
-	p.domainFilterDDI = make([]string, 0)
+	now := time.Now()
+	if p.domainFilterDDI != nil && now.Sub(p.domainFilterDDIUpdated) < p.config.ZoneRefreshInterval {
+		return nil
+	}
 
-	for _, domain := range p.domainFilter.Filters {
-		if !p.client.ZoneExist(domain) {
+	filter := make(map[string][]string)
+	var checks []ZoneKey
+	for _, view := range p.config.AllViews() {
+		// Created zones are not listed in the domain filter, so discover them
+		if p.domainFilterSpec.discoverZones() || p.config.CreateZones {
+			zones, err := p.discoverZones(ctx, view)
+			if err != nil {
+				return fmt.Errorf("refresh zones: %w", err)
+			}
+			filter[view] = zones
 			continue
 		}
 
-		p.domainFilterDDI = append(p.domainFilterDDI, domain)
+		filter[view] = make([]string, 0)
+		for _, domain := range p.domainFilter.Filters {
+			checks = append(checks, ZoneKey{View: view, Zone: domain})
+		}
+	}
+
+	exists := make([]bool, len(checks))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.zoneConcurrency())
+	for i, zk := range checks {
+		g.Go(func() error {
+			exist, err := p.zoneExist(gctx, zk.View, zk.Zone)
+			exists[i] = exist
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("refresh zones: %w", err)
+	}
+	for i, zk := range checks {
+		if exists[i] {
+			filter[zk.View] = append(filter[zk.View], zk.Zone)
+		}
+	}
+
+	p.domainFilterDDI = filter
+	p.domainFilterDDIUpdated = now
+
+	return nil
+}
+
+// discoverZones returns the authoritative zones of the view that match the
+// domain filter.
+func (p *Provider) discoverZones(ctx context.Context, view string) ([]string, error) {
+	zones, err := p.client.ListZones(ctx, view)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(zones))
+	created := make([]string, 0)
+	for _, zone := range zones {
+		name := domain.NewDomain(zone.Name).ToDomain().ToString()
+		if name == "" || arrays.Contains(names, name) || !p.domainFilterSpec.matchZone(name) {
+			continue
+		}
+		names = append(names, name)
+		if p.config.owns(zone.Source) {
+			created = append(created, name)
+		}
+	}
+	log.Debugf("discovered zones in view %s: %v", view, names)
+	if len(created) > 0 {
+		log.Infof("zones created by the webhook in view %s: %v", view, created)
 	}
+
+	return names, nil
 }
 
-// getDDIDomainFilter
-func (p *Provider) getDDIDomainFilter() []string {
+// zoneExist checks if a zone exists in the view, consulting the zone cache
+// first.
+func (p *Provider) zoneExist(ctx context.Context, view, zone string) (bool, error) {
+	zk := ZoneKey{View: view, Zone: zone}
+	if exist, ok := p.zoneCache.get(zk); ok {
+		return exist, nil
+	}
+
+	exist, err := p.client.ZoneExist(ctx, view, zone)
+	if err != nil {
+		return false, err
+	}
+	p.zoneCache.set(zk, exist)
+
+	return exist, nil
+}
+
+// InvalidateZoneCache drops all cached zone lookups and records, so the next
+// Records or ApplyChanges call asks YamuDDI again.
+func (p *Provider) InvalidateZoneCache() {
+	p.zoneCache.invalidate()
+	p.recordCache.invalidate()
+
+	p.domainFilterDDIRWMux.Lock()
+	defer p.domainFilterDDIRWMux.Unlock()
+	p.domainFilterDDI = nil
+
+	log.Info("zone cache invalidated")
+}
+
+// PlannedRequests returns the mutating requests the last ApplyChanges call
+// would have sent in DRY_RUN mode.
+func (p *Provider) PlannedRequests() any {
+	return p.client.dryRun.list()
+}
+
+// Ready returns the YamuDDI node in use. It reports an error while the
+// circuit breaker to YamuDDI is open.
+func (p *Provider) Ready() (string, error) {
+	node := p.client.nodes.current().name
+	if state := p.client.breaker.State(); state == breakerOpen {
+		return "", fmt.Errorf("ddi circuit breaker is %s, node %s", state, node)
+	}
+
+	return "active node: " + node, nil
+}
+
+// getDDIDomainFilter returns the zones of the view that exist in YamuDDI.
+func (p *Provider) getDDIDomainFilter(view string) []string {
 	p.domainFilterDDIRWMux.RLock()
 	defer p.domainFilterDDIRWMux.RUnlock()
 
-	return p.domainFilterDDI
+	return p.domainFilterDDI[view]
+}
+
+// addDDIZone adds a zone to the zones of the view that exist in YamuDDI.
+func (p *Provider) addDDIZone(view, zone string) {
+	p.domainFilterDDIRWMux.Lock()
+	defer p.domainFilterDDIRWMux.Unlock()
+
+	if p.domainFilterDDI == nil {
+		p.domainFilterDDI = make(map[string][]string)
+	}
+	if !arrays.Contains(p.domainFilterDDI[view], zone) {
+		p.domainFilterDDI[view] = append(p.domainFilterDDI[view], zone)
+	}
+}
+
+// ddiZones returns the zones of every configured view, in view order.
+func (p *Provider) ddiZones() []ZoneKey {
+	zks := make([]ZoneKey, 0)
+	for _, view := range p.config.AllViews() {
+		for _, zone := range p.getDDIDomainFilter(view) {
+			zks = append(zks, ZoneKey{View: view, Zone: zone})
+		}
+	}
+
+	return zks
+}
+
+// endpointView returns the view an endpoint is published in.
+func (p *Provider) endpointView(ep *endpoint.Endpoint) string {
+	if view, ok := ep.GetProviderSpecificProperty(providerSpecificView); ok && view != "" {
+		return view
+	}
+
+	return p.config.View
+}
+
+// cacheEntries returns copies of the endpoints in the form Records reports
+// them, leaving out endpoints that are not written to YamuDDI.
+func (p *Provider) cacheEntries(eps []*endpoint.Endpoint) map[recordCacheKey]*endpoint.Endpoint {
+	entries := make(map[recordCacheKey]*endpoint.Endpoint, len(eps))
+	for _, ep := range eps {
+		view := p.endpointView(ep)
+		if !arrays.Contains(supportTypes, ep.RecordType) || !arrays.Contains(p.config.AllViews(), view) {
+			continue
+		}
+		if _, suff := domain.SplitSuffixToDomain(ep.DNSName, p.getDDIDomainFilter(view)); suff == "" {
+			continue
+		}
+
+		c := &endpoint.Endpoint{
+			DNSName:    ep.DNSName,
+			RecordType: ep.RecordType,
+			RecordTTL:  ep.RecordTTL,
+			Targets:    append(endpoint.Targets{}, ep.Targets...),
+		}
+		if view != p.config.View {
+			c.WithProviderSpecific(providerSpecificView, view)
+		}
+		entries[recordCacheKey{View: view, DNSName: ep.DNSName, RecordType: ep.RecordType}] = c
+	}
+
+	return entries
 }
 
 // convertDnsRecord converts the endpoint to DNSRecord.
-func (p *Provider) convertDnsRecord(req []*endpoint.Endpoint) (map[string][]*DNSRecord, error) {
-	rd := make(map[string][]*DNSRecord, 0)
+func (p *Provider) convertDnsRecord(req []*endpoint.Endpoint) (map[ZoneKey][]*DNSRecord, error) {
+	rd := make(map[ZoneKey][]*DNSRecord, 0)
 	for _, ep := range req {
 		if !arrays.Contains(supportTypes, ep.RecordType) {
 			log.Infof("RecordType %s is not supported", ep.RecordType)
+			endpointsSkipped.WithLabelValues(skipUnsupportedType).Inc()
+			continue
+		}
+		view := p.endpointView(ep)
+		if !arrays.Contains(p.config.AllViews(), view) {
+			log.Infof("View %s of %v is not configured", view, ep.DNSName)
+			endpointsSkipped.WithLabelValues(skipUnknownView).Inc()
 			continue
 		}
-		pre, suff := domain.SplitSuffixToDomain(ep.DNSName, p.getDDIDomainFilter())
+		pre, suff := domain.SplitSuffixToDomain(ep.DNSName, p.getDDIDomainFilter(view))
 		if suff == "" {
 			log.Infof("Does not match zone: %v", ep.DNSName)
+			endpointsSkipped.WithLabelValues(skipNoZone).Inc()
 			continue
 		}
 
-		if _, ok := rd[suff]; !ok {
-			rd[suff] = make([]*DNSRecord, 0)
+		zk := ZoneKey{View: view, Zone: suff}
+		if _, ok := rd[zk]; !ok {
+			rd[zk] = make([]*DNSRecord, 0)
 		}
 
 		for _, target := range ep.Targets {
+			rdata, err := targetToRdata(ep.RecordType, target)
+			if err != nil {
+				log.Infof("Invalid %s target %s of %s: %v", ep.RecordType, target, ep.DNSName, err)
+				endpointsSkipped.WithLabelValues(skipInvalidTarget).Inc()
+				continue
+			}
+
 			dnsr := &DNSRecord{
 				Name:        pre,
 				Rtype:       ep.RecordType,
 				TTL:         uint32(ep.RecordTTL),
 				TTLStrategy: strategyRewrite,
-				Rdata:       target,
+				Rdata:       rdata,
 
 				Enabled: true,
-				Source:  source,
+				Source:  p.config.owner(),
 			}
 			if p.client.Config.DefaultTTL == 0 && ep.RecordTTL == 0 {
 				dnsr.TTLStrategy = strategyInherit
@@ -200,14 +681,30 @@ func (p *Provider) convertDnsRecord(req []*endpoint.Endpoint) (map[string][]*DNS
 				// if the TTL is not set and the default TTL is not 0, use the default TTL
 				dnsr.TTL = p.client.Config.DefaultTTL
 			}
-			rd[suff] = append(rd[suff], dnsr)
+			rd[zk] = append(rd[zk], dnsr)
 		}
 	}
 
 	return rd, nil
 }
 
-// GetDomainFilter returns the domain filter for the provider.
+// GetDomainFilter returns the domain filter for the provider. When zones are
+// discovered from YamuDDI, it includes the discovered zones.
 func (p *Provider) GetDomainFilter() endpoint.DomainFilter {
-	return p.domainFilter
+	if !p.domainFilterSpec.discoverZones() {
+		return p.domainFilter
+	}
+
+	if err := p.setDDIDomainFilter(context.Background()); err != nil {
+		log.Errorf("domain filter: %v", err)
+	}
+
+	zones := make([]string, 0)
+	for _, zk := range p.ddiZones() {
+		if !arrays.Contains(zones, zk.Zone) {
+			zones = append(zones, zk.Zone)
+		}
+	}
+
+	return p.domainFilterSpec.withZones(zones)
 }
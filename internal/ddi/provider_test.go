@@ -2,8 +2,19 @@ package ddi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
@@ -60,3 +71,380 @@ func TestRecords(t *testing.T) {
 		t.Errorf("TestCreateHostOverride=%v, wantNumOfRRs!=%v", err, len(ds))
 	}
 }
+
+func TestAdjustEndpointsView(t *testing.T) {
+	eps, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("www.test.com", "A", "1.1.1.1").WithProviderSpecific(providerSpecificView, "default"),
+		endpoint.NewEndpoint("www.test.com", "A", "1.1.1.1").WithProviderSpecific(providerSpecificView, "intranet"),
+	})
+	if err != nil {
+		t.Fatalf("AdjustEndpoints() error = %v", err)
+	}
+	if _, ok := eps[0].GetProviderSpecificProperty(providerSpecificView); ok {
+		t.Errorf("AdjustEndpoints() kept default view on %v", eps[0])
+	}
+	if view, _ := eps[1].GetProviderSpecificProperty(providerSpecificView); view != "intranet" {
+		t.Errorf("AdjustEndpoints() view = %v, want %v", view, "intranet")
+	}
+}
+
+func TestDiscoverZones(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi/dns/zone/auth/view/default" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"name":"test.com."},{"name":"internal.test.com"},{"name":"other.com"}]}`))
+	})
+
+	dp := &Provider{
+		client:           c,
+		domainFilter:     endpoint.NewDomainFilterWithExclusions(nil, []string{"internal.test.com"}),
+		domainFilterSpec: newDomainFilterSpec(endpoint.NewDomainFilterWithExclusions(nil, []string{"internal.test.com"})),
+		zoneCache:        newZoneCache(0, 0),
+		config:           c.Config,
+	}
+
+	got, err := dp.GetDomainFilter().MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"include":["other.com","test.com"],"exclude":["internal.test.com"]}`
+	if string(got) != want {
+		t.Errorf("GetDomainFilter() = %s, want %s", got, want)
+	}
+}
+
+func TestFilterOwned(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("source"); got != "cluster-a" {
+			t.Errorf("source = %v, want cluster-a", got)
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"name":"www","qtype":"A","rdata":"1.1.1.1","source":"cluster-a"},
+			{"name":"www","qtype":"A","rdata":"2.2.2.2","source":"cluster-b"}
+		]}`))
+	})
+	c.OwnerID = "cluster-a"
+	dp := &Provider{client: c, config: c.Config}
+
+	got, err := dp.filterOwned(context.Background(), ZoneKey{View: "default", Zone: "test.com"}, []*DNSRecord{
+		{Name: "www", Rtype: "A", Rdata: "1.1.1.1"},
+		{Name: "www", Rtype: "A", Rdata: "2.2.2.2"},
+		{Name: "api", Rtype: "A", Rdata: "1.1.1.1"},
+	})
+	if err != nil {
+		t.Fatalf("filterOwned() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Rdata != "1.1.1.1" || got[0].Name != "www" {
+		t.Errorf("filterOwned() = %+v, want only www A 1.1.1.1", got)
+	}
+}
+
+func TestRecordsConcurrent(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "merged in zone order",
+			want: []string{"a.a.com", "b.a.com", "a.b.com", "b.b.com", "a.c.com", "b.c.com"},
+		},
+		{
+			name:    "one zone fails",
+			failing: "b.com",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.failing != "" && strings.HasSuffix(r.URL.Path, "/zone/"+tt.failing) {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":[
+					{"name":"b","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"},
+					{"name":"a","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"},
+					{"name":"c","qtype":"A","rdata":"1.1.1.1"}
+				]}`))
+			}, "a.com", "b.com", "c.com")
+			dp.config.ZoneConcurrency = 2
+
+			eps, err := dp.Records(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Records() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := make([]string, 0, len(eps))
+			for _, ep := range eps {
+				got = append(got, ep.DNSName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Records() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordsCached(t *testing.T) {
+	var gets atomic.Int32
+	dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"name":"www","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"}]}`))
+	}, "test.com")
+	dp.config.RecordsCacheMaxAge = time.Hour
+	dp.recordCache = newRecordCache(dp.config.RecordsCacheMaxAge)
+
+	for i := 0; i < 2; i++ {
+		if _, err := dp.Records(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := gets.Load(); n != 1 {
+		t.Errorf("GET requests = %d, want 1", n)
+	}
+
+	err := dp.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("api.test.com", "A", "2.2.2.2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eps, err := dp.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := gets.Load(); n != 1 {
+		t.Errorf("GET requests = %d, want 1", n)
+	}
+	if len(eps) != 2 || eps[0].DNSName != "api.test.com" || eps[1].DNSName != "www.test.com" {
+		t.Errorf("Records() = %v, want api.test.com and www.test.com", eps)
+	}
+}
+
+func TestApplyChangesDryRun(t *testing.T) {
+	dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("%s %s sent in dry run mode", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"name":"www","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"}]}`))
+	}, "test.com")
+	dp.config.DryRun = true
+
+	err := dp.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("api.test.com", "A", "2.2.2.2")},
+		Delete: []*endpoint.Endpoint{endpoint.NewEndpoint("www.test.com", "A", "1.1.1.1")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := dp.PlannedRequests().([]PlannedRequest)
+	if len(got) != 2 {
+		t.Fatalf("PlannedRequests() = %+v, want a delete and a create", got)
+	}
+	if got[0].Method != http.MethodDelete || got[1].Method != http.MethodPost {
+		t.Errorf("PlannedRequests() methods = %s, %s, want DELETE, POST", got[0].Method, got[1].Method)
+	}
+	if got[1].Path != "/openapi/dns/zone/auth/rr/view/default/zone/test.com" {
+		t.Errorf("PlannedRequests() path = %s", got[1].Path)
+	}
+	if !strings.Contains(string(got[1].Body), `"name":"api"`) {
+		t.Errorf("PlannedRequests() body = %s, want the api record", got[1].Body)
+	}
+}
+
+// fakeDDI is a test server keeping the records of one zone. Like YamuDDI, it
+// identifies records by name, type and rdata and rejects duplicates.
+type fakeDDI struct {
+	mu      sync.Mutex
+	records []*DNSRecord
+	// failPosts is the number of creates answered with 503 before any is
+	// served.
+	failPosts int
+}
+
+func (f *fakeDDI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(respRRs{Data: f.records})
+	case http.MethodPost:
+		if f.failPosts > 0 {
+			f.failPosts--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var rrs []*DNSRecord
+		if err := json.NewDecoder(r.Body).Decode(&rrs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rr := range rrs {
+			if f.find(rr) >= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"rcode":2,"description":"record exists"}`))
+				return
+			}
+		}
+		f.records = append(f.records, rrs...)
+	case http.MethodDelete:
+		var del DNSRecordsDel
+		if err := json.NewDecoder(r.Body).Decode(&del); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, rr := range del.RRs {
+			if i := f.find(rr); i >= 0 {
+				f.records = append(f.records[:i], f.records[i+1:]...)
+			}
+		}
+	}
+}
+
+func (f *fakeDDI) find(rr *DNSRecord) int {
+	for i, e := range f.records {
+		if e.Name == rr.Name && e.Rtype == rr.Rtype && fmt.Sprint(e.Rdata) == fmt.Sprint(rr.Rdata) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestApplyChangesTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		failPosts int
+	}{
+		{name: "replaced"},
+		{name: "create retried", failPosts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddi := &fakeDDI{
+				records: []*DNSRecord{
+					{Name: "www", Rtype: "A", TTL: 30, TTLStrategy: strategyRewrite, Rdata: "1.1.1.1", Enabled: true, Source: defaultOwnerID},
+				},
+				failPosts: tt.failPosts,
+			}
+			dp := newTestProvider(t, ddi.ServeHTTP, "test.com")
+			dp.config.RetryMax = 2
+
+			err := dp.ApplyChanges(context.Background(), &plan.Changes{
+				UpdateOld: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.test.com", "A", 30, "1.1.1.1")},
+				UpdateNew: []*endpoint.Endpoint{endpoint.NewEndpointWithTTL("www.test.com", "A", 60, "1.1.1.1")},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ddi.records) != 1 || ddi.records[0].TTL != 60 {
+				t.Errorf("records after ApplyChanges() = %+v, want www A 1.1.1.1 with TTL 60", ddi.records)
+			}
+		})
+	}
+}
+
+func TestRecordsManagedRecords(t *testing.T) {
+	var failing atomic.Bool
+	dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"name":"www","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"},
+			{"name":"api","qtype":"A","rdata":"1.1.1.1","source":"external-dns-yamu"}
+		]}`))
+	}, "managed.com")
+	managed := func() float64 {
+		return testutil.ToFloat64(managedRecords.WithLabelValues("default", "managed.com"))
+	}
+
+	if _, err := dp.Records(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := managed(); got != 2 {
+		t.Errorf("managed_records = %v, want 2", got)
+	}
+
+	// A failed read keeps the last counts
+	failing.Store(true)
+	if _, err := dp.Records(context.Background()); err == nil {
+		t.Fatal("Records() succeeded during an outage")
+	}
+	if got := managed(); got != 2 {
+		t.Errorf("managed_records after a failed read = %v, want 2", got)
+	}
+}
+
+func TestCreateRecordsChunks(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		posts = map[string][][]string{}
+	)
+	dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var rrs []*DNSRecord
+		if err := json.NewDecoder(r.Body).Decode(&rrs); err != nil {
+			t.Errorf("decode %s %s: %v", r.Method, r.URL.Path, err)
+		}
+		zone := path.Base(r.URL.Path)
+		names := make([]string, 0, len(rrs))
+		for _, rr := range rrs {
+			names = append(names, rr.Name)
+		}
+
+		mu.Lock()
+		posts[zone] = append(posts[zone], names)
+		mu.Unlock()
+
+		if names[0] == "a2" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"rcode":3,"description":"invalid rdata"}`))
+		}
+	}, "a.test", "b.test")
+	dp.config.CreateBatchSize = 2
+
+	zkA := ZoneKey{View: "default", Zone: "a.test"}
+	zkB := ZoneKey{View: "default", Zone: "b.test"}
+	ds := map[ZoneKey][]*DNSRecord{zkB: {{Name: "b0", Rtype: "A", Rdata: "2.2.2.2"}}}
+	for i := 0; i < 5; i++ {
+		ds[zkA] = append(ds[zkA], &DNSRecord{Name: fmt.Sprintf("a%d", i), Rtype: "A", Rdata: "1.1.1.1"})
+	}
+
+	j := newJournal(dp.client)
+	err := dp.createRecords(context.Background(), j, ds)
+
+	want := map[string][][]string{
+		"a.test": {{"a0", "a1"}, {"a2", "a3"}, {"a4"}},
+		"b.test": {{"b0"}},
+	}
+	if !reflect.DeepEqual(posts, want) {
+		t.Errorf("createRecords() posts = %v, want %v", posts, want)
+	}
+
+	if err == nil || !strings.HasPrefix(err.Error(), "chunk 2/3: ") || strings.Contains(err.Error(), "\n") {
+		t.Fatalf("createRecords() error = %v, want chunk 2/3 only", err)
+	}
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.RCode != 3 {
+		t.Errorf("createRecords() error = %v, want the rejection of YamuDDI", err)
+	}
+	if len(j.created[zkA]) != 3 || len(j.created[zkB]) != 1 {
+		t.Errorf("createRecords() journal = %v, want the records of the sent chunks", j.created)
+	}
+}
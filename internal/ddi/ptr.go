@@ -0,0 +1,219 @@
+package ddi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	reverseZoneV4 = "in-addr.arpa"
+	reverseZoneV6 = "ip6.arpa"
+)
+
+// reverseName returns the reverse lookup name of an IP address.
+func reverseName(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.%s", v4[3], v4[2], v4[1], v4[0], reverseZoneV4)
+	}
+
+	const hex = "0123456789abcdef"
+	v6 := ip.To16()
+	labels := make([]string, 0, 2*net.IPv6len+1)
+	for i := net.IPv6len - 1; i >= 0; i-- {
+		labels = append(labels, string(hex[v6[i]&0x0f]), string(hex[v6[i]>>4]))
+	}
+	labels = append(labels, reverseZoneV6)
+
+	return strings.Join(labels, ".")
+}
+
+// reverseZoneCandidates returns the zones a reverse name may belong to, most
+// specific first.
+func reverseZoneCandidates(name string) []string {
+	candidates := make([]string, 0)
+	for {
+		i := strings.Index(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+		candidates = append(candidates, name)
+		if name == reverseZoneV4 || name == reverseZoneV6 {
+			break
+		}
+	}
+
+	return candidates
+}
+
+// ptrPlanner computes the PTR records belonging to forward A/AAAA records
+// during a single ApplyChanges call.
+type ptrPlanner struct {
+	client    *httpClient
+	zoneExist func(ctx context.Context, view, zone string) (bool, error)
+	zones     map[ZoneKey]bool
+	existing  map[ZoneKey][]*DNSRecord
+	deleted   map[string]bool
+	skipped   []string
+}
+
+func newPTRPlanner(client *httpClient, zoneExist func(ctx context.Context, view, zone string) (bool, error)) *ptrPlanner {
+	return &ptrPlanner{
+		client:    client,
+		zoneExist: zoneExist,
+		zones:     map[ZoneKey]bool{},
+		existing:  map[ZoneKey][]*DNSRecord{},
+		deleted:   map[string]bool{},
+	}
+}
+
+// deletes returns the PTR records to delete for the given forward records.
+// Only PTRs written by this webhook are deleted.
+func (pl *ptrPlanner) deletes(ctx context.Context, forward map[ZoneKey][]*DNSRecord) (map[ZoneKey][]*DNSRecord, error) {
+	return pl.plan(ctx, forward, func(zk ZoneKey, ptr *DNSRecord, existing []*DNSRecord) bool {
+		for _, rr := range existing {
+			if pl.client.owns(rr.Source) && sameTarget(rr.Rdata, ptr.Rdata) {
+				pl.deleted[ptrKey(zk, ptr)] = true
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// creates returns the PTR records to create for the given forward records.
+// Names that already have a PTR owned by someone else are skipped.
+func (pl *ptrPlanner) creates(ctx context.Context, forward map[ZoneKey][]*DNSRecord) (map[ZoneKey][]*DNSRecord, error) {
+	return pl.plan(ctx, forward, func(zk ZoneKey, ptr *DNSRecord, existing []*DNSRecord) bool {
+		for _, rr := range existing {
+			if !pl.client.owns(rr.Source) {
+				name := domain.HostAddDomain(ptr.Name, zk.Zone)
+				log.Warnf("ptr: %s in view %s is owned by %q, skipped", name, zk.View, rr.Source)
+				pl.skipped = append(pl.skipped, name)
+				endpointsSkipped.WithLabelValues(skipPTROtherOwner).Inc()
+				return false
+			}
+			if sameTarget(rr.Rdata, ptr.Rdata) && !pl.deleted[ptrKey(zk, ptr)] {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// plan builds PTR records for every A/AAAA record in forward and keeps those
+// accepted by keep, grouped by reverse zone.
+func (pl *ptrPlanner) plan(ctx context.Context, forward map[ZoneKey][]*DNSRecord,
+	keep func(zk ZoneKey, ptr *DNSRecord, existing []*DNSRecord) bool) (map[ZoneKey][]*DNSRecord, error) {
+	rd := make(map[ZoneKey][]*DNSRecord)
+	for zk, rrs := range forward {
+		for _, rr := range rrs {
+			if rr.Rtype != "A" && rr.Rtype != "AAAA" {
+				continue
+			}
+
+			ip := net.ParseIP(fmt.Sprintf("%v", rr.Rdata))
+			if ip == nil {
+				log.Infof("ptr: invalid address %v of %s", rr.Rdata, rr.Name)
+				continue
+			}
+
+			name := reverseName(ip)
+			rzone, err := pl.zone(ctx, zk.View, name)
+			if err != nil {
+				return nil, err
+			}
+			if rzone == "" {
+				log.Infof("ptr: no reverse zone for %s in view %s", name, zk.View)
+				continue
+			}
+
+			pre, _ := domain.SplitSuffixToDomain(name, []string{rzone})
+			ptr := &DNSRecord{
+				Name:        pre,
+				Rtype:       "PTR",
+				TTL:         rr.TTL,
+				TTLStrategy: rr.TTLStrategy,
+				Rdata:       domain.NewDomain(domain.HostAddDomain(rr.Name, zk.Zone)).ToFQDN().ToString(),
+
+				Enabled: true,
+				Source:  pl.client.owner(),
+			}
+
+			rzk := ZoneKey{View: zk.View, Zone: rzone}
+			existing, err := pl.records(ctx, rzk, ptr.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !keep(rzk, ptr, existing) {
+				continue
+			}
+
+			rd[rzk] = append(rd[rzk], ptr)
+		}
+	}
+
+	return rd, nil
+}
+
+// zone returns the most specific reverse zone of name that exists in the view.
+func (pl *ptrPlanner) zone(ctx context.Context, view, name string) (string, error) {
+	for _, candidate := range reverseZoneCandidates(name) {
+		zk := ZoneKey{View: view, Zone: candidate}
+		exist, ok := pl.zones[zk]
+		if !ok {
+			var err error
+			exist, err = pl.zoneExist(ctx, view, candidate)
+			if err != nil {
+				return "", err
+			}
+			pl.zones[zk] = exist
+		}
+		if exist {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
+}
+
+// records returns the existing PTR records named name in the zone.
+func (pl *ptrPlanner) records(ctx context.Context, zk ZoneKey, name string) ([]*DNSRecord, error) {
+	all, ok := pl.existing[zk]
+	if !ok {
+		var err error
+		all, err = pl.client.GetAllHostOverrides(ctx, zk.View, zk.Zone)
+		if err != nil {
+			return nil, err
+		}
+		pl.existing[zk] = all
+	}
+
+	rrs := make([]*DNSRecord, 0)
+	for _, rr := range all {
+		if rr.Rtype == "PTR" && strings.EqualFold(rr.Name, name) {
+			rrs = append(rrs, rr)
+		}
+	}
+
+	return rrs, nil
+}
+
+func ptrKey(zk ZoneKey, ptr *DNSRecord) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s/%s/%v", zk.View, zk.Zone, ptr.Name, ptr.Rdata))
+}
+
+// sameTarget reports whether two domain name rdata values are equal.
+func sameTarget(a, b any) bool {
+	return strings.EqualFold(
+		domain.NewDomain(fmt.Sprintf("%v", a)).ToFQDN().ToString(),
+		domain.NewDomain(fmt.Sprintf("%v", b)).ToFQDN().ToString(),
+	)
+}
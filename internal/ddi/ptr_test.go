@@ -0,0 +1,165 @@
+package ddi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{
+			name: "ipv4",
+			ip:   "10.233.71.55",
+			want: "55.71.233.10.in-addr.arpa",
+		},
+		{
+			name: "ipv6",
+			ip:   "2001:db8::1",
+			want: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reverseName(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("reverseName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReverseZoneCandidates(t *testing.T) {
+	got := reverseZoneCandidates("55.71.233.10.in-addr.arpa")
+	want := []string{"71.233.10.in-addr.arpa", "233.10.in-addr.arpa", "10.in-addr.arpa", "in-addr.arpa"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reverseZoneCandidates() = %v, want %v", got, want)
+	}
+}
+
+func TestPTRPlanner(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi/dns/zone/auth/rr/all/view/default/zone/0.10.in-addr.arpa" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"name":"1.0","qtype":"PTR","rdata":"www.test.com.","source":"external-dns-yamu"},
+			{"name":"2.0","qtype":"PTR","rdata":"db.test.com.","source":"dba"}
+		]}`))
+	})
+	// The most specific existing zone wins over 10.in-addr.arpa
+	zones := map[string]bool{"0.10.in-addr.arpa": true, "10.in-addr.arpa": true}
+	zoneExist := func(_ context.Context, _, zone string) (bool, error) {
+		return zones[zone], nil
+	}
+
+	zk := ZoneKey{View: "default", Zone: "test.com"}
+	forward := func(name, ip string) map[ZoneKey][]*DNSRecord {
+		return map[ZoneKey][]*DNSRecord{zk: {{Name: name, Rtype: "A", Rdata: ip}}}
+	}
+	ptrs := func(rd map[ZoneKey][]*DNSRecord) []string {
+		got := make([]string, 0)
+		for rzk, rrs := range rd {
+			for _, rr := range rrs {
+				got = append(got, fmt.Sprintf("%s %s %v", rzk.Zone, rr.Name, rr.Rdata))
+			}
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	tests := []struct {
+		name        string
+		dels        map[ZoneKey][]*DNSRecord
+		adds        map[ZoneKey][]*DNSRecord
+		wantDels    []string
+		wantAdds    []string
+		wantSkipped []string
+	}{
+		{
+			name:     "create",
+			adds:     forward("api", "10.0.0.3"),
+			wantDels: []string{},
+			wantAdds: []string{"0.10.in-addr.arpa 3.0 api.test.com."},
+		},
+		{
+			name:     "create existing",
+			adds:     forward("www", "10.0.0.1"),
+			wantDels: []string{},
+			wantAdds: []string{},
+		},
+		{
+			name:        "create owned by other",
+			adds:        forward("db", "10.0.0.2"),
+			wantDels:    []string{},
+			wantAdds:    []string{},
+			wantSkipped: []string{"2.0.0.10.in-addr.arpa"},
+		},
+		{
+			name:     "delete",
+			dels:     forward("www", "10.0.0.1"),
+			wantDels: []string{"0.10.in-addr.arpa 1.0 www.test.com."},
+			wantAdds: []string{},
+		},
+		{
+			name:     "delete owned by other",
+			dels:     forward("db", "10.0.0.2"),
+			wantDels: []string{},
+			wantAdds: []string{},
+		},
+		{
+			name:     "update",
+			dels:     forward("www", "10.0.0.1"),
+			adds:     forward("www", "10.0.0.1"),
+			wantDels: []string{"0.10.in-addr.arpa 1.0 www.test.com."},
+			wantAdds: []string{"0.10.in-addr.arpa 1.0 www.test.com."},
+		},
+		{
+			name:     "no reverse zone",
+			adds:     forward("lan", "192.168.0.1"),
+			wantDels: []string{},
+			wantAdds: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skipped := testutil.ToFloat64(endpointsSkipped.WithLabelValues(skipPTROtherOwner))
+			pl := newPTRPlanner(c, zoneExist)
+
+			dels, err := pl.deletes(context.Background(), tt.dels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			adds, err := pl.creates(context.Background(), tt.adds)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ptrs(dels); !reflect.DeepEqual(got, tt.wantDels) {
+				t.Errorf("deletes() = %v, want %v", got, tt.wantDels)
+			}
+			if got := ptrs(adds); !reflect.DeepEqual(got, tt.wantAdds) {
+				t.Errorf("creates() = %v, want %v", got, tt.wantAdds)
+			}
+			if !reflect.DeepEqual(pl.skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", pl.skipped, tt.wantSkipped)
+			}
+			got := testutil.ToFloat64(endpointsSkipped.WithLabelValues(skipPTROtherOwner)) - skipped
+			if got != float64(len(tt.wantSkipped)) {
+				t.Errorf("endpoints_skipped_total{reason=%q} increased by %v, want %d", skipPTROtherOwner, got, len(tt.wantSkipped))
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package ddi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// targetToRdata converts an external-dns target into the rdata sent to the
+// YamuDDI API for the given record type.
+func targetToRdata(rtype, target string) (any, error) {
+	switch rtype {
+	case "TXT":
+		return txtTargetToRdata(target)
+	case "MX":
+		return parseMX(target)
+	case "SRV":
+		return parseSRV(target)
+	case "CAA":
+		return parseCAA(target)
+	case "NS":
+		target = strings.TrimSpace(target)
+		if target == "" {
+			return nil, fmt.Errorf("ns: empty target")
+		}
+		return target, nil
+	default:
+		return target, nil
+	}
+}
+
+// rdataToTarget converts rdata returned by the YamuDDI API into an
+// external-dns target for the given record type.
+func rdataToTarget(rtype string, rdata any) (string, error) {
+	switch rtype {
+	case "CNAME", "NS":
+		return strings.TrimSuffix(fmt.Sprintf("%v", rdata), "."), nil
+	case "TXT":
+		return txtRdataToTarget(fmt.Sprintf("%v", rdata))
+	case "MX":
+		mx, err := decodeRdata(rdata, parseMX)
+		if err != nil {
+			return "", err
+		}
+		return mx.String(), nil
+	case "SRV":
+		srv, err := decodeRdata(rdata, parseSRV)
+		if err != nil {
+			return "", err
+		}
+		return srv.String(), nil
+	case "CAA":
+		caa, err := decodeRdata(rdata, parseCAA)
+		if err != nil {
+			return "", err
+		}
+		return caa.String(), nil
+	default:
+		return fmt.Sprintf("%v", rdata), nil
+	}
+}
+
+// canonicalTarget normalizes a target to the form returned by Records.
+// Targets that cannot be parsed are returned unchanged.
+func canonicalTarget(rtype, target string) string {
+	rdata, err := targetToRdata(rtype, target)
+	if err != nil {
+		return target
+	}
+
+	t, err := rdataToTarget(rtype, rdata)
+	if err != nil {
+		return target
+	}
+
+	return t
+}
+
+// decodeRdata decodes structured rdata returned by the YamuDDI API. Rdata
+// returned in presentation format is parsed with parse.
+func decodeRdata[T any](rdata any, parse func(string) (*T, error)) (*T, error) {
+	if s, ok := rdata.(string); ok {
+		return parse(s)
+	}
+
+	b, err := json.Marshal(rdata)
+	if err != nil {
+		return nil, err
+	}
+
+	v := new(T)
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// parseMX parses an MX target such as "10 mail.example.com".
+func parseMX(target string) (*MXRdata, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("mx: want \"preference exchange\", got %q", target)
+	}
+
+	pref, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("mx: bad preference in %q: %w", target, err)
+	}
+
+	return &MXRdata{Preference: uint16(pref), Exchange: fields[1]}, nil
+}
+
+func (mx *MXRdata) String() string {
+	return fmt.Sprintf("%d %s", mx.Preference, strings.TrimSuffix(mx.Exchange, "."))
+}
+
+// parseSRV parses an SRV target such as "10 5 443 svc.example.com".
+func parseSRV(target string) (*SRVRdata, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("srv: want \"priority weight port target\", got %q", target)
+	}
+
+	nums := make([]uint16, 3)
+	for i := range nums {
+		n, err := strconv.ParseUint(fields[i], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("srv: bad number in %q: %w", target, err)
+		}
+		nums[i] = uint16(n)
+	}
+
+	return &SRVRdata{Priority: nums[0], Weight: nums[1], Port: nums[2], Target: fields[3]}, nil
+}
+
+func (srv *SRVRdata) String() string {
+	return fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, strings.TrimSuffix(srv.Target, "."))
+}
+
+// parseCAA parses a CAA target such as `0 issue "letsencrypt.org"`.
+func parseCAA(target string) (*CAARdata, error) {
+	fields := strings.SplitN(strings.TrimSpace(target), " ", 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("caa: want \"flag tag value\", got %q", target)
+	}
+
+	flag, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("caa: bad flag in %q: %w", target, err)
+	}
+
+	value, err := txtJoin(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("caa: bad value in %q: %w", target, err)
+	}
+
+	return &CAARdata{Flag: uint8(flag), Tag: strings.ToLower(fields[1]), Value: value}, nil
+}
+
+func (caa *CAARdata) String() string {
+	return fmt.Sprintf("%d %s %s", caa.Flag, caa.Tag, txtQuote(caa.Value))
+}
+
+// txtMaxStringLen is the maximum length of a single TXT character-string.
+const txtMaxStringLen = 255
+
+// txtTargetToRdata converts an external-dns TXT target into the presentation
+// format accepted by the YamuDDI API. Long values are split into multiple
+// character-strings of at most 255 bytes each.
+func txtTargetToRdata(target string) (string, error) {
+	value, err := txtJoin(target)
+	if err != nil {
+		return "", err
+	}
+
+	chunks := make([]string, 0, len(value)/txtMaxStringLen+1)
+	for len(value) > txtMaxStringLen {
+		chunks = append(chunks, txtQuote(value[:txtMaxStringLen]))
+		value = value[txtMaxStringLen:]
+	}
+	chunks = append(chunks, txtQuote(value))
+
+	return strings.Join(chunks, " "), nil
+}
+
+// txtRdataToTarget converts TXT rdata returned by the YamuDDI API into the
+// canonical external-dns target, a single quoted string.
+func txtRdataToTarget(rdata string) (string, error) {
+	value, err := txtJoin(rdata)
+	if err != nil {
+		return "", err
+	}
+
+	return txtQuote(value), nil
+}
+
+// txtJoin parses a TXT value and returns its character-strings concatenated.
+// A value that does not start with a quote is taken literally.
+func txtJoin(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	strs, err := txtSplit(s)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(strs, ""), nil
+}
+
+// txtSplit parses a sequence of quoted character-strings, such as
+// `"foo" "bar"`, resolving backslash escapes.
+func txtSplit(s string) ([]string, error) {
+	var (
+		strs    []string
+		sb      strings.Builder
+		inQuote bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case !inQuote && c == '"':
+			inQuote = true
+			sb.Reset()
+		case !inQuote && (c == ' ' || c == '\t'):
+		case !inQuote:
+			return nil, fmt.Errorf("txt: unexpected character %q outside quotes in %s", c, s)
+		case c == '"':
+			inQuote = false
+			strs = append(strs, sb.String())
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("txt: dangling escape in %s", s)
+			}
+			if isDigit(s[i+1]) {
+				if i+3 >= len(s) || !isDigit(s[i+2]) || !isDigit(s[i+3]) {
+					return nil, fmt.Errorf("txt: bad decimal escape in %s", s)
+				}
+				v := int(s[i+1]-'0')*100 + int(s[i+2]-'0')*10 + int(s[i+3]-'0')
+				if v > 255 {
+					return nil, fmt.Errorf("txt: bad decimal escape in %s", s)
+				}
+				sb.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+			sb.WriteByte(s[i+1])
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("txt: unterminated quote in %s", s)
+	}
+
+	return strs, nil
+}
+
+// txtQuote quotes a single character-string, escaping quotes and backslashes.
+func txtQuote(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('"')
+
+	return sb.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
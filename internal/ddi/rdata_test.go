@@ -0,0 +1,214 @@
+package ddi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTxtTargetToRdata(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	tests := []struct {
+		name    string
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "registry",
+			target: `"heritage=external-dns,external-dns/owner=default"`,
+			want:   `"heritage=external-dns,external-dns/owner=default"`,
+		},
+		{
+			name:   "unquoted",
+			target: `hello world`,
+			want:   `"hello world"`,
+		},
+		{
+			name:   "escape",
+			target: `say "hi" \ bye`,
+			want:   `"say \"hi\" \\ bye"`,
+		},
+		{
+			name:   "multi string",
+			target: `"foo" "bar"`,
+			want:   `"foobar"`,
+		},
+		{
+			name:   "long",
+			target: long,
+			want:   `"` + long[:255] + `" "` + long[255:] + `"`,
+		},
+		{
+			name:    "unterminated",
+			target:  `"foo`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := txtTargetToRdata(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("txtTargetToRdata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("txtTargetToRdata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTxtRdataToTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		rdata   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "single",
+			rdata: `"heritage=external-dns"`,
+			want:  `"heritage=external-dns"`,
+		},
+		{
+			name:  "multi string",
+			rdata: `"foo" "bar"`,
+			want:  `"foobar"`,
+		},
+		{
+			name:  "escape",
+			rdata: `"a\"b\\c\100"`,
+			want:  `"a\"b\\cd"`,
+		},
+		{
+			name:  "unquoted",
+			rdata: `plain`,
+			want:  `"plain"`,
+		},
+		{
+			name:    "garbage",
+			rdata:   `"foo" bar`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := txtRdataToTarget(tt.rdata)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("txtRdataToTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("txtRdataToTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetToRdata(t *testing.T) {
+	tests := []struct {
+		name    string
+		rtype   string
+		target  string
+		want    any
+		wantErr bool
+	}{
+		{
+			name:   "mx",
+			rtype:  "MX",
+			target: "10 mail.example.com",
+			want:   &MXRdata{Preference: 10, Exchange: "mail.example.com"},
+		},
+		{
+			name:   "srv",
+			rtype:  "SRV",
+			target: "10 5 443 svc.example.com.",
+			want:   &SRVRdata{Priority: 10, Weight: 5, Port: 443, Target: "svc.example.com."},
+		},
+		{
+			name:   "caa",
+			rtype:  "CAA",
+			target: `0 issue "letsencrypt.org"`,
+			want:   &CAARdata{Flag: 0, Tag: "issue", Value: "letsencrypt.org"},
+		},
+		{
+			name:   "ns",
+			rtype:  "NS",
+			target: "ns1.example.com",
+			want:   "ns1.example.com",
+		},
+		{
+			name:    "mx bad preference",
+			rtype:   "MX",
+			target:  "high mail.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "srv missing port",
+			rtype:   "SRV",
+			target:  "10 5 svc.example.com",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := targetToRdata(tt.rtype, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("targetToRdata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("targetToRdata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRdataToTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		rtype string
+		rdata any
+		want  string
+	}{
+		{
+			name:  "mx",
+			rtype: "MX",
+			rdata: map[string]any{"preference": float64(10), "exchange": "mail.example.com."},
+			want:  "10 mail.example.com",
+		},
+		{
+			name:  "srv",
+			rtype: "SRV",
+			rdata: map[string]any{"priority": float64(10), "weight": float64(5), "port": float64(443), "target": "svc.example.com."},
+			want:  "10 5 443 svc.example.com",
+		},
+		{
+			name:  "caa",
+			rtype: "CAA",
+			rdata: map[string]any{"flag": float64(128), "tag": "issue", "value": "letsencrypt.org"},
+			want:  `128 issue "letsencrypt.org"`,
+		},
+		{
+			name:  "mx presentation",
+			rtype: "MX",
+			rdata: "20 mx.example.com.",
+			want:  "20 mx.example.com",
+		},
+		{
+			name:  "ns",
+			rtype: "NS",
+			rdata: "ns1.example.com.",
+			want:  "ns1.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rdataToTarget(tt.rtype, tt.rdata)
+			if err != nil {
+				t.Fatalf("rdataToTarget() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rdataToTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
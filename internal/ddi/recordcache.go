@@ -0,0 +1,112 @@
+package ddi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// recordCacheKey identifies an endpoint of the record cache.
+type recordCacheKey struct {
+	View       string
+	DNSName    string
+	RecordType string
+}
+
+// recordCache holds the last Records result. It is kept up to date by the
+// changes the webhook applies and refetched once it is older than maxAge, so
+// changes made outside the webhook are still picked up.
+type recordCache struct {
+	mu        sync.Mutex
+	endpoints map[recordCacheKey]*endpoint.Endpoint
+	fetched   time.Time
+	maxAge    time.Duration
+	now       func() time.Time
+}
+
+// newRecordCache returns a record cache. A maxAge of 0 disables caching.
+func newRecordCache(maxAge time.Duration) *recordCache {
+	return &recordCache{
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+}
+
+func (rc *recordCache) enabled() bool {
+	return rc.maxAge > 0
+}
+
+// get returns a copy of the cached endpoints and whether they are fresh.
+func (rc *recordCache) get() ([]*endpoint.Endpoint, bool) {
+	if !rc.enabled() {
+		return nil, false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.endpoints == nil || rc.now().Sub(rc.fetched) >= rc.maxAge {
+		return nil, false
+	}
+
+	keys := make([]recordCacheKey, 0, len(rc.endpoints))
+	for k := range rc.endpoints {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].View != keys[j].View {
+			return keys[i].View < keys[j].View
+		}
+		if keys[i].DNSName != keys[j].DNSName {
+			return keys[i].DNSName < keys[j].DNSName
+		}
+		return keys[i].RecordType < keys[j].RecordType
+	})
+
+	eps := make([]*endpoint.Endpoint, 0, len(keys))
+	for _, k := range keys {
+		eps = append(eps, rc.endpoints[k].DeepCopy())
+	}
+
+	return eps, true
+}
+
+// set replaces the cached endpoints with a fresh result from YamuDDI.
+func (rc *recordCache) set(eps map[recordCacheKey]*endpoint.Endpoint) {
+	if !rc.enabled() {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.endpoints = eps
+	rc.fetched = rc.now()
+}
+
+// update applies changes made by the webhook to the cached endpoints,
+// keeping their age.
+func (rc *recordCache) update(removed, added map[recordCacheKey]*endpoint.Endpoint) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.endpoints == nil {
+		return
+	}
+	for k := range removed {
+		delete(rc.endpoints, k)
+	}
+	for k, ep := range added {
+		rc.endpoints[k] = ep
+	}
+}
+
+// invalidate drops the cached endpoints, so the next get misses.
+func (rc *recordCache) invalidate() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.endpoints = nil
+}
@@ -0,0 +1,50 @@
+package ddi
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestRecordCache(t *testing.T) {
+	now := time.Now()
+	rc := newRecordCache(time.Minute)
+	rc.now = func() time.Time { return now }
+
+	if _, ok := rc.get(); ok {
+		t.Fatal("get() hit on an empty cache")
+	}
+
+	www := recordCacheKey{View: "default", DNSName: "www.test.com", RecordType: "A"}
+	api := recordCacheKey{View: "default", DNSName: "api.test.com", RecordType: "A"}
+	rc.set(map[recordCacheKey]*endpoint.Endpoint{
+		www: endpoint.NewEndpoint("www.test.com", "A", "1.1.1.1"),
+	})
+	rc.update(
+		map[recordCacheKey]*endpoint.Endpoint{www: nil},
+		map[recordCacheKey]*endpoint.Endpoint{api: endpoint.NewEndpoint("api.test.com", "A", "2.2.2.2")},
+	)
+
+	eps, ok := rc.get()
+	if !ok || len(eps) != 1 || eps[0].DNSName != "api.test.com" {
+		t.Fatalf("get() = %v, %v, want api.test.com only", eps, ok)
+	}
+
+	// Callers must not be able to change the cache
+	eps[0].Targets[0] = "3.3.3.3"
+	if eps, _ := rc.get(); eps[0].Targets[0] != "2.2.2.2" {
+		t.Errorf("cached target changed to %s", eps[0].Targets[0])
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := rc.get(); ok {
+		t.Error("get() hit after max age")
+	}
+
+	now = now.Add(-time.Minute)
+	rc.invalidate()
+	if _, ok := rc.get(); ok {
+		t.Error("get() hit after invalidate")
+	}
+}
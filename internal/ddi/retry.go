@@ -0,0 +1,143 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// retry calls fn until it succeeds, fails permanently or YAMU_RETRY_MAX
+// retries are used up, backing off exponentially with jitter in between.
+// If before is set it runs ahead of every retry; it may report that the
+// request no longer needs to be sent.
+func (c *httpClient) retry(ctx context.Context, method, path string, fn func() error, before func() (bool, error)) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 0 {
+				log.Infof("retry: %s %s succeeded after %d retries", method, path, attempt)
+			}
+			return nil
+		}
+
+		if attempt >= c.RetryMax || !isRetryable(ctx, err) {
+			if attempt > 0 {
+				log.Errorf("retry: %s %s failed after %d retries: %v", method, path, attempt, err)
+			}
+			ddiRequestFailures.WithLabelValues(method).Inc()
+			return err
+		}
+
+		wait := c.backoff(attempt, err)
+		log.Warnf("retry: %s %s failed (attempt %d/%d), retrying in %s: %v",
+			method, path, attempt+1, c.RetryMax+1, wait, err)
+		ddiRequestRetries.WithLabelValues(method).Inc()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			ddiRequestFailures.WithLabelValues(method).Inc()
+			return err
+		case <-timer.C:
+		}
+
+		if before == nil {
+			continue
+		}
+		done, berr := before()
+		if berr != nil {
+			log.Errorf("retry: %s %s could not be verified: %v", method, path, berr)
+			ddiRequestFailures.WithLabelValues(method).Inc()
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// backoff returns the wait before retry attempt+1: the exponential backoff
+// with jitter, or the Retry-After of the response if that is longer.
+func (c *httpClient) backoff(attempt int, err error) time.Duration {
+	d := c.RetryInitialBackoff << attempt
+	if d <= 0 || d > c.RetryMaxBackoff {
+		d = c.RetryMaxBackoff
+	}
+	if d > 0 {
+		// Full jitter in the upper half keeps some spacing between retries
+		d = d/2 + rand.N(d/2+1)
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		d = apiErr.RetryAfter
+	}
+
+	return d
+}
+
+// isRetryable reports whether a failed request may succeed when sent again.
+func isRetryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && isTransient(err)
+}
+
+// isTransient reports whether err is a temporary failure of YamuDDI rather
+// than a problem with the request.
+func isTransient(err error) bool {
+	var permErr *permanentError
+	if errors.Is(err, ErrCircuitOpen) || errors.As(err, &permErr) {
+		return false
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Connection errors and per-call timeouts. Client.Do returns a
+	// *url.Error, which is a net.Error.
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isIdempotent reports whether sending a request twice has the same effect
+// as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns 0 if the header is missing or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
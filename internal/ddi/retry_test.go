@@ -0,0 +1,116 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	c.RetryMax = 3
+	c.RetryInitialBackoff = time.Millisecond
+	c.RetryMaxBackoff = 5 * time.Millisecond
+
+	if _, err := c.GetHostOverrides(context.Background(), "default", "test.com"); err != nil {
+		t.Fatalf("GetHostOverrides() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("GetHostOverrides() calls = %v, want 3", got)
+	}
+}
+
+func TestRetryPermanent(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	})
+	c.RetryMax = 3
+	c.RetryInitialBackoff = time.Millisecond
+
+	if _, err := c.GetHostOverrides(context.Background(), "default", "test.com"); err == nil {
+		t.Fatalf("GetHostOverrides() error = nil, want error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("GetHostOverrides() calls = %v, want 1", got)
+	}
+}
+
+func TestRetryMalformedAnswer(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`not json`))
+	})
+	c.RetryMax = 3
+	c.RetryInitialBackoff = time.Millisecond
+
+	_, err := c.GetHostOverrides(context.Background(), "default", "test.com")
+	if err == nil {
+		t.Fatalf("GetHostOverrides() error = nil, want error")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("GetHostOverrides() calls = %v, want 1", got)
+	}
+	if isTransient(err) || isNodeDown(err) {
+		t.Errorf("GetHostOverrides() error = %v is transient", err)
+	}
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Retryable() {
+		t.Errorf("GetHostOverrides() error = %v is retryable", err)
+	}
+}
+
+func TestRetryCreateVerifies(t *testing.T) {
+	var posts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			// The record is written but the response is lost
+			posts.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"data":[{"name":"www","qtype":"A","rdata":"123.123.123.123"}]}`))
+		}
+	})
+	c.RetryMax = 3
+	c.RetryInitialBackoff = time.Millisecond
+
+	if err := c.CreateHostOverride(context.Background(), "default", "test.com", addRRs["testA"]); err != nil {
+		t.Fatalf("CreateHostOverride() error = %v", err)
+	}
+	if got := posts.Load(); got != 1 {
+		t.Errorf("CreateHostOverride() posts = %v, want 1", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "seconds", value: "3", want: 3 * time.Second},
+		{name: "invalid", value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package ddi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsFiles holds the CA bundle and client certificate loaded from the files
+// of config, so they can be replaced when the files are rotated.
+type tlsFiles struct {
+	config *Config
+
+	mu    sync.RWMutex
+	roots *x509.CertPool
+	cert  *tls.Certificate
+}
+
+// newTLSConfig returns the TLS configuration for connections to YamuDDI. The
+// returned tlsFiles is nil if no certificate files are configured.
+func newTLSConfig(config *Config) (*tls.Config, *tlsFiles, error) {
+	minVersion, ok := tlsVersions[config.TLSMinVersion]
+	if config.TLSMinVersion == "" {
+		minVersion, ok = tls.VersionTLS12, true
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("YAMU_TLS_MIN_VERSION: unknown version %q", config.TLSMinVersion)
+	}
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, nil, errors.New("YAMU_TLS_CERT_FILE and YAMU_TLS_KEY_FILE must be set together")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         config.TLSServerName,
+		InsecureSkipVerify: config.SkipTLSVerify,
+	}
+	if config.SkipTLSVerify {
+		log.Warn("tls: YAMU_DDI_SKIP_TLS_VERIFY is set, the certificate of YamuDDI is not verified")
+	}
+
+	if config.TLSCAFile == "" && config.TLSCertFile == "" {
+		return tlsConfig, nil, nil
+	}
+
+	tf := &tlsFiles{config: config}
+	if err := tf.load(); err != nil {
+		return nil, nil, err
+	}
+
+	if config.TLSCAFile != "" && !config.SkipTLSVerify {
+		// Verify against the current CA bundle instead of a fixed RootCAs
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = tf.verifyConnection
+	}
+	if config.TLSCertFile != "" {
+		tlsConfig.GetClientCertificate = tf.clientCertificate
+	}
+
+	return tlsConfig, tf, nil
+}
+
+// files returns the files to watch for changes.
+func (tf *tlsFiles) files() []string {
+	var files []string
+	for _, f := range []string{tf.config.TLSCAFile, tf.config.TLSCertFile, tf.config.TLSKeyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// load reads the certificate files. On error the previous certificates are
+// kept.
+func (tf *tlsFiles) load() error {
+	var roots *x509.CertPool
+	if tf.config.TLSCAFile != "" {
+		pem, err := os.ReadFile(tf.config.TLSCAFile)
+		if err != nil {
+			return fmt.Errorf("read CA bundle: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("CA bundle %s has no certificates", tf.config.TLSCAFile)
+		}
+	}
+
+	var cert *tls.Certificate
+	if tf.config.TLSCertFile != "" {
+		c, err := tls.LoadX509KeyPair(tf.config.TLSCertFile, tf.config.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		cert = &c
+	}
+
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	tf.roots = roots
+	tf.cert = cert
+
+	return nil
+}
+
+func (tf *tlsFiles) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	tf.mu.RLock()
+	defer tf.mu.RUnlock()
+
+	return tf.cert, nil
+}
+
+// verifyConnection verifies the server certificate against the CA bundle.
+func (tf *tlsFiles) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: no server certificate")
+	}
+
+	tf.mu.RLock()
+	roots := tf.roots
+	tf.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       cs.ServerName,
+	})
+
+	return err
+}
+
+// logUnknownAuthority logs, once, how to make the certificate of YamuDDI
+// trusted when err shows it is signed by an unknown authority. Earlier
+// versions skipped verification by default, so upgrades without
+// YAMU_TLS_CA_FILE fail here.
+func (c *httpClient) logUnknownAuthority(err error) {
+	var uaErr x509.UnknownAuthorityError
+	if !errors.As(err, &uaErr) {
+		return
+	}
+
+	c.unknownAuthority.Do(func() {
+		if c.TLSCAFile == "" {
+			log.Errorf("tls: the certificate of YamuDDI is signed by an unknown authority: %v. "+
+				"YAMU_DDI_SKIP_TLS_VERIFY defaults to false, set YAMU_TLS_CA_FILE to the CA certificate of YamuDDI", err)
+			return
+		}
+		log.Errorf("tls: the certificate of YamuDDI is not signed by the CA in YAMU_TLS_CA_FILE %s: %v", c.TLSCAFile, err)
+	})
+}
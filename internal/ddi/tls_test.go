@@ -0,0 +1,207 @@
+package ddi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// writeCert writes a self-signed certificate and its key to dir.
+func writeCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig, tf, err := newTLSConfig(&Config{TLSCAFile: caFile, TLSServerName: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every request does a handshake, so none can reuse a pooled connection
+	transport := &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET with the server CA error = %v", err)
+	}
+	resp.Body.Close()
+
+	// Rotate to a CA that did not sign the server certificate
+	otherCA, _ := writeCert(t, dir, "other")
+	if err := os.Rename(otherCA, caFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("GET with the rotated CA succeeded")
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "client")
+	clientPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientPEM)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:    "without client certificate",
+			config:  Config{Host: srv.URL, OpenAPITimeout: 5, SkipTLSVerify: true},
+			wantErr: true,
+		},
+		{
+			name:   "with client certificate",
+			config: Config{Host: srv.URL, OpenAPITimeout: 5, SkipTLSVerify: true, TLSCertFile: certFile, TLSKeyFile: keyFile},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newYamuDDIClient(&tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.ListZones(context.Background(), "default"); (err != nil) != tt.wantErr {
+				t.Errorf("ListZones() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    uint16
+		wantErr bool
+	}{
+		{name: "secure default", config: Config{}, want: tls.VersionTLS12},
+		{name: "tls 1.3", config: Config{TLSMinVersion: "1.3"}, want: tls.VersionTLS13},
+		{name: "unknown version", config: Config{TLSMinVersion: "2.0"}, wantErr: true},
+		{name: "cert without key", config: Config{TLSCertFile: "client.crt"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := newTLSConfig(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.MinVersion != tt.want || got.InsecureSkipVerify) {
+				t.Errorf("newTLSConfig() = MinVersion %x, InsecureSkipVerify %v, want %x, false",
+					got.MinVersion, got.InsecureSkipVerify, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogUnknownAuthority(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+
+	hooks := log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+	t.Cleanup(func() { log.StandardLogger().ReplaceHooks(hooks) })
+	hook := logtest.NewLocal(log.StandardLogger())
+
+	c, err := newYamuDDIClient(&Config{Host: srv.URL, OpenAPITimeout: 5, View: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListZones(context.Background(), "default"); err == nil {
+			t.Fatal("ListZones() with an unknown CA succeeded")
+		}
+	}
+
+	var hints int
+	for _, e := range hook.AllEntries() {
+		if strings.Contains(e.Message, "set YAMU_TLS_CA_FILE") {
+			hints++
+		}
+	}
+	if hints != 1 {
+		t.Errorf("logged %d hints to set YAMU_TLS_CA_FILE, want 1", hints)
+	}
+}
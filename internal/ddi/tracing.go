@@ -0,0 +1,29 @@
+package ddi
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Yamu-OSS/external-dns-yamu-webhook/internal/ddi"
+
+// endSpan records the outcome of a DDI API request on its span.
+func endSpan(span trace.Span, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", 200))
+		return
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		span.SetAttributes(attribute.Int("http.response.status_code", apiErr.StatusCode))
+		if apiErr.RCode != 0 {
+			span.SetAttributes(attribute.Int("ddi.rcode", int(apiErr.RCode)))
+		}
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
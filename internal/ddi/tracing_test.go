@@ -0,0 +1,51 @@
+package ddi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	if _, err := c.ZoneExist(context.Background(), "default", "test.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if got, want := spans[0].Name(), "ddi GET zone/auth/view/{view}/zone/{zone}"; got != want {
+		t.Errorf("span name = %s, want %s", got, want)
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		"ddi.view":                  attribute.StringValue("default"),
+		"ddi.zone":                  attribute.StringValue("test.com"),
+		"http.response.status_code": attribute.IntValue(http.StatusNotFound),
+	}
+	for _, kv := range spans[0].Attributes() {
+		if v, ok := want[kv.Key]; ok {
+			if kv.Value != v {
+				t.Errorf("span attribute %s = %v, want %v", kv.Key, kv.Value.Emit(), v.Emit())
+			}
+			delete(want, kv.Key)
+		}
+	}
+	for k := range want {
+		t.Errorf("span attribute %s missing", k)
+	}
+}
@@ -1,15 +1,96 @@
 package ddi
 
+import (
+	"time"
+
+	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/arrays"
+)
+
 // Config represents the configuration for the UniFi API.
 type Config struct {
+	// Host is a comma separated list of YamuDDI nodes, the first is preferred.
 	Host           string `env:"YAMU_HOST,notEmpty"`
-	User           string `env:"YAMU_API_USER,notEmpty"`
-	Key            string `env:"YAMU_API_KEY,notEmpty"`
+	User           string `env:"YAMU_API_USER"`
+	UserFile       string `env:"YAMU_API_USER_FILE"`
+	Key            string `env:"YAMU_API_KEY"`
+	KeyFile        string `env:"YAMU_API_KEY_FILE"`
 	OpenAPITimeout int    `env:"YAMU_OPENAPI_TIMEOUT" envDefault:"60"`
-	SkipTLSVerify  bool   `env:"YAMU_DDI_SKIP_TLS_VERIFY" envDefault:"true"`
+	SkipTLSVerify  bool   `env:"YAMU_DDI_SKIP_TLS_VERIFY" envDefault:"false"`
+
+	TLSCAFile          string        `env:"YAMU_TLS_CA_FILE"`
+	TLSCertFile        string        `env:"YAMU_TLS_CERT_FILE"`
+	TLSKeyFile         string        `env:"YAMU_TLS_KEY_FILE"`
+	TLSMinVersion      string        `env:"YAMU_TLS_MIN_VERSION" envDefault:"1.2"`
+	TLSServerName      string        `env:"YAMU_TLS_SERVER_NAME"`
+	FileReloadInterval time.Duration `env:"YAMU_FILE_RELOAD_INTERVAL" envDefault:"30s"`
+
+	OwnerID    string   `env:"OWNER_ID"`
+	View       string   `env:"VIEW" envDefault:"default"`
+	Views      []string `env:"VIEWS" envDefault:""`
+	DefaultTTL uint32   `env:"DEFAULT_TTL" envDefault:"0"`
+	CreatePTR  bool     `env:"CREATE_PTR" envDefault:"false"`
+	DryRun     bool     `env:"DRY_RUN" envDefault:"false"`
+
+	CreateBatchSize int `env:"CREATE_BATCH_SIZE" envDefault:"100"`
+
+	RetryMax            int           `env:"YAMU_RETRY_MAX" envDefault:"3"`
+	RetryInitialBackoff time.Duration `env:"YAMU_RETRY_INITIAL_BACKOFF" envDefault:"500ms"`
+	RetryMaxBackoff     time.Duration `env:"YAMU_RETRY_MAX_BACKOFF" envDefault:"10s"`
+
+	BreakerFailures    int           `env:"YAMU_BREAKER_FAILURES" envDefault:"5"`
+	BreakerOpenTimeout time.Duration `env:"YAMU_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+
+	HealthCheckInterval time.Duration `env:"YAMU_HEALTH_CHECK_INTERVAL" envDefault:"30s"`
+
+	RateLimit           float64 `env:"YAMU_RATE_LIMIT" envDefault:"0"`
+	RateBurst           int     `env:"YAMU_RATE_BURST" envDefault:"0"`
+	MaxConcurrency      int     `env:"YAMU_MAX_CONCURRENCY" envDefault:"0"`
+	WriteRateLimit      float64 `env:"YAMU_WRITE_RATE_LIMIT" envDefault:"0"`
+	WriteRateBurst      int     `env:"YAMU_WRITE_RATE_BURST" envDefault:"0"`
+	WriteMaxConcurrency int     `env:"YAMU_WRITE_MAX_CONCURRENCY" envDefault:"0"`
+
+	ZoneRefreshInterval  time.Duration `env:"ZONE_REFRESH_INTERVAL" envDefault:"1m"`
+	ZoneCacheTTL         time.Duration `env:"ZONE_CACHE_TTL" envDefault:"10m"`
+	ZoneCacheNegativeTTL time.Duration `env:"ZONE_CACHE_NEGATIVE_TTL" envDefault:"1m"`
+	ZoneConcurrency      int           `env:"ZONE_CONCURRENCY" envDefault:"4"`
+
+	RecordsCacheMaxAge time.Duration `env:"RECORDS_CACHE_MAX_AGE" envDefault:"0"`
+
+	CreateZones       bool     `env:"CREATE_ZONES" envDefault:"false"`
+	CreateZoneParents []string `env:"CREATE_ZONE_PARENTS" envDefault:""`
+	CreateZoneNS      []string `env:"CREATE_ZONE_NS" envDefault:""`
+	CreateZoneSOA     string   `env:"CREATE_ZONE_SOA" envDefault:"{ns} hostmaster.{zone} 1 3600 900 604800 300"`
+}
+
+// defaultOwnerID is the owner ID written by webhooks without OWNER_ID.
+const defaultOwnerID = "external-dns-yamu"
+
+// owner returns the owner ID written into the source of every record:
+// OWNER_ID, or defaultOwnerID if it is not set.
+func (c *Config) owner() string {
+	if c.OwnerID == "" {
+		return defaultOwnerID
+	}
+
+	return c.OwnerID
+}
+
+// owns reports whether a record or zone with the given source was written by
+// this webhook. Records without a source are not owned.
+func (c *Config) owns(source string) bool {
+	return source == c.owner()
+}
+
+// AllViews returns the default view followed by the additional views.
+func (c *Config) AllViews() []string {
+	views := []string{c.View}
+	for _, view := range c.Views {
+		if view != "" && !arrays.Contains(views, view) {
+			views = append(views, view)
+		}
+	}
 
-	View       string `env:"VIEW" envDefault:"default"`
-	DefaultTTL uint32 `env:"DEFAULT_TTL" envDefault:"0"`
+	return views
 }
 
 // DNSRecord represents a DNS record in the YamuDDI API.
@@ -24,6 +105,46 @@ type DNSRecord struct {
 	Source  string `json:"source"`
 }
 
+// MXRdata represents the rdata of an MX record in the YamuDDI API.
+type MXRdata struct {
+	Preference uint16 `json:"preference"`
+	Exchange   string `json:"exchange"`
+}
+
+// SRVRdata represents the rdata of an SRV record in the YamuDDI API.
+type SRVRdata struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+// CAARdata represents the rdata of a CAA record in the YamuDDI API.
+type CAARdata struct {
+	Flag  uint8  `json:"flag"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// Zone represents an authoritative zone in the YamuDDI API.
+type Zone struct {
+	Name   string   `json:"name"`
+	SOA    *ZoneSOA `json:"soa,omitempty"`
+	NS     []string `json:"ns,omitempty"`
+	Source string   `json:"source,omitempty"`
+}
+
+// ZoneSOA represents the SOA of a zone in the YamuDDI API.
+type ZoneSOA struct {
+	Mname   string `json:"mname"`
+	Rname   string `json:"rname"`
+	Serial  uint32 `json:"serial"`
+	Refresh uint32 `json:"refresh"`
+	Retry   uint32 `json:"retry"`
+	Expire  uint32 `json:"expire"`
+	Minimum uint32 `json:"minimum"`
+}
+
 type respCode struct {
 	RCode       int32  `json:"rcode"`
 	Description string `json:"description"`
@@ -33,6 +154,10 @@ type respRRs struct {
 	Data []*DNSRecord `json:"data"`
 }
 
+type respZones struct {
+	Data []*Zone `json:"data"`
+}
+
 type DNSRecordsDel struct {
 	RRs []*DNSRecord `json:"rrs"`
 }
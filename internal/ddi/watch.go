@@ -0,0 +1,54 @@
+package ddi
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(name string) fileStamp {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}
+	}
+
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+// watchFiles calls reload whenever one of the files changes, checking every
+// interval until ctx is done. Symlinks are followed, so the atomic updates of
+// mounted Kubernetes secrets are noticed.
+func watchFiles(ctx context.Context, interval time.Duration, files []string, reload func()) {
+	stamps := make([]fileStamp, len(files))
+	for i, f := range files {
+		stamps[i] = statFile(f)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed := false
+		for i, f := range files {
+			if s := statFile(f); s != stamps[i] {
+				stamps[i] = s
+				changed = true
+			}
+		}
+		if changed {
+			reload()
+		}
+	}
+}
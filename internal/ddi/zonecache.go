@@ -0,0 +1,75 @@
+package ddi
+
+import (
+	"sync"
+	"time"
+)
+
+// zoneCache caches zone existence lookups, with separate lifetimes for zones
+// that exist and zones that do not.
+type zoneCache struct {
+	mu          sync.Mutex
+	entries     map[ZoneKey]zoneCacheEntry
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+type zoneCacheEntry struct {
+	exist   bool
+	expires time.Time
+}
+
+func newZoneCache(positiveTTL, negativeTTL time.Duration) *zoneCache {
+	return &zoneCache{
+		entries:     map[ZoneKey]zoneCacheEntry{},
+		positiveTTL: positiveTTL,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
+
+// get returns the cached existence of a zone and whether it was cached.
+func (zc *zoneCache) get(zk ZoneKey) (exist, ok bool) {
+	zc.mu.Lock()
+	defer zc.mu.Unlock()
+
+	e, ok := zc.entries[zk]
+	if !ok || !zc.now().Before(e.expires) {
+		return false, false
+	}
+
+	return e.exist, true
+}
+
+// set caches the existence of a zone.
+func (zc *zoneCache) set(zk ZoneKey, exist bool) {
+	ttl := zc.negativeTTL
+	if exist {
+		ttl = zc.positiveTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	zc.mu.Lock()
+	defer zc.mu.Unlock()
+
+	zc.entries[zk] = zoneCacheEntry{exist: exist, expires: zc.now().Add(ttl)}
+}
+
+// forget drops the cached entry of a zone.
+func (zc *zoneCache) forget(zk ZoneKey) {
+	zc.mu.Lock()
+	defer zc.mu.Unlock()
+
+	delete(zc.entries, zk)
+}
+
+// invalidate drops every cached entry.
+func (zc *zoneCache) invalidate() {
+	zc.mu.Lock()
+	defer zc.mu.Unlock()
+
+	zc.entries = map[ZoneKey]zoneCacheEntry{}
+}
@@ -0,0 +1,37 @@
+package ddi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneCache(t *testing.T) {
+	now := time.Now()
+	zc := newZoneCache(time.Minute, time.Second)
+	zc.now = func() time.Time { return now }
+
+	pos := ZoneKey{View: "default", Zone: "test.com"}
+	neg := ZoneKey{View: "default", Zone: "missing.com"}
+	zc.set(pos, true)
+	zc.set(neg, false)
+
+	if exist, ok := zc.get(pos); !ok || !exist {
+		t.Errorf("get(%v) = %v, %v, want true, true", pos, exist, ok)
+	}
+	if exist, ok := zc.get(neg); !ok || exist {
+		t.Errorf("get(%v) = %v, %v, want false, true", neg, exist, ok)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := zc.get(neg); ok {
+		t.Errorf("get(%v) cached after negative TTL", neg)
+	}
+	if _, ok := zc.get(pos); !ok {
+		t.Errorf("get(%v) expired before positive TTL", pos)
+	}
+
+	zc.invalidate()
+	if _, ok := zc.get(pos); ok {
+		t.Errorf("get(%v) cached after invalidate", pos)
+	}
+}
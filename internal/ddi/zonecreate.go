@@ -0,0 +1,142 @@
+package ddi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/arrays"
+	"github.com/Yamu-OSS/external-dns-yamu-webhook/pkg/domain"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// validateZoneCreation checks the zone creation settings of config.
+func validateZoneCreation(config *Config) error {
+	if !config.CreateZones {
+		return nil
+	}
+	if len(config.CreateZoneParents) == 0 {
+		return errors.New("CREATE_ZONES requires CREATE_ZONE_PARENTS")
+	}
+	if len(config.CreateZoneNS) == 0 {
+		return errors.New("CREATE_ZONES requires CREATE_ZONE_NS")
+	}
+	if _, err := newZone("example.com", config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createZoneName returns the zone to create for dnsName: the name one label
+// below the longest matching parent. It returns "" if no parent allows it.
+func createZoneName(dnsName string, parents []string) string {
+	pre, parent := domain.SplitSuffixToDomain(dnsName, parents)
+	if parent == "" || pre == "" || parent == "." {
+		return ""
+	}
+
+	labels := strings.Split(pre, ".")
+
+	return domain.HostAddDomain(labels[len(labels)-1], parent)
+}
+
+// newZone builds a zone tagged with the owner ID from the CREATE_ZONE_NS and
+// CREATE_ZONE_SOA templates. Both may refer to the zone name as {zone}; the SOA may refer to the first
+// name server as {ns}.
+func newZone(name string, config *Config) (*Zone, error) {
+	expand := func(s string) string {
+		s = strings.ReplaceAll(s, "{zone}", name)
+		return strings.ReplaceAll(s, "{ns}", expandZone(config.CreateZoneNS[0], name))
+	}
+
+	fields := strings.Fields(expand(config.CreateZoneSOA))
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("CREATE_ZONE_SOA: want \"mname rname serial refresh retry expire minimum\", got %q", config.CreateZoneSOA)
+	}
+
+	nums := make([]uint32, 5)
+	for i := range nums {
+		n, err := strconv.ParseUint(fields[i+2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("CREATE_ZONE_SOA: bad number %q: %w", fields[i+2], err)
+		}
+		nums[i] = uint32(n)
+	}
+
+	ns := make([]string, 0, len(config.CreateZoneNS))
+	for _, n := range config.CreateZoneNS {
+		ns = append(ns, expandZone(n, name))
+	}
+
+	return &Zone{
+		Name: name,
+		SOA: &ZoneSOA{
+			Mname:   fields[0],
+			Rname:   fields[1],
+			Serial:  nums[0],
+			Refresh: nums[1],
+			Retry:   nums[2],
+			Expire:  nums[3],
+			Minimum: nums[4],
+		},
+		NS:     ns,
+		Source: config.owner(),
+	}, nil
+}
+
+func expandZone(s, zone string) string {
+	return strings.ReplaceAll(s, "{zone}", zone)
+}
+
+// ensureZones creates the missing zones of endpoints whose name is below one
+// of CREATE_ZONE_PARENTS. Created zones are tagged with the owner ID and
+// recorded in the journal.
+func (p *Provider) ensureZones(ctx context.Context, j *journal, eps []*endpoint.Endpoint) error {
+	for _, ep := range eps {
+		view := p.endpointView(ep)
+		if !arrays.Contains(supportTypes, ep.RecordType) || !arrays.Contains(p.config.AllViews(), view) {
+			continue
+		}
+		if _, suff := domain.SplitSuffixToDomain(ep.DNSName, p.getDDIDomainFilter(view)); suff != "" {
+			continue
+		}
+
+		name := createZoneName(ep.DNSName, p.config.CreateZoneParents)
+		if name == "" {
+			continue
+		}
+
+		zk := ZoneKey{View: view, Zone: name}
+		exist, err := p.zoneExist(ctx, view, name)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			zone, err := newZone(name, p.config)
+			if err != nil {
+				return err
+			}
+			if err := p.client.CreateZone(ctx, view, zone); err != nil {
+				// The zone may have been created before the failure
+				p.zoneCache.forget(zk)
+				return err
+			}
+			if p.config.DryRun {
+				// The zone does not exist, so its records cannot be planned
+				log.Infof("apply: dry run, records in zone %s view %s are not planned", name, view)
+				continue
+			}
+			log.Infof("apply: created zone %s in view %s", name, view)
+			j.zoneCreated(zk)
+		}
+
+		p.zoneCache.set(zk, true)
+		p.addDDIZone(view, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package ddi
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestCreateZoneName(t *testing.T) {
+	parents := []string{"apps.example.com", "example.org"}
+	tests := []struct {
+		name    string
+		dnsName string
+		want    string
+	}{
+		{
+			name:    "below parent",
+			dnsName: "web.team-a.apps.example.com",
+			want:    "team-a.apps.example.com",
+		},
+		{
+			name:    "zone apex",
+			dnsName: "team-b.apps.example.com",
+			want:    "team-b.apps.example.com",
+		},
+		{
+			name:    "parent itself",
+			dnsName: "apps.example.com",
+			want:    "",
+		},
+		{
+			name:    "not allowed",
+			dnsName: "web.example.net",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := createZoneName(tt.dnsName, parents); got != tt.want {
+				t.Errorf("createZoneName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewZone(t *testing.T) {
+	config := &Config{
+		CreateZoneNS:  []string{"ns1.example.com.", "ns2.example.com."},
+		CreateZoneSOA: "{ns} hostmaster.{zone}. 1 3600 900 604800 300",
+	}
+
+	got, err := newZone("team-a.apps.example.com", config)
+	if err != nil {
+		t.Fatalf("newZone() error = %v", err)
+	}
+
+	want := &Zone{
+		Name: "team-a.apps.example.com",
+		SOA: &ZoneSOA{
+			Mname:   "ns1.example.com.",
+			Rname:   "hostmaster.team-a.apps.example.com.",
+			Serial:  1,
+			Refresh: 3600,
+			Retry:   900,
+			Expire:  604800,
+			Minimum: 300,
+		},
+		NS:     []string{"ns1.example.com.", "ns2.example.com."},
+		Source: defaultOwnerID,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newZone() = %+v, want %+v", got, want)
+	}
+
+	config.CreateZoneSOA = "{ns} hostmaster.{zone}. 1 3600"
+	if _, err := newZone("team-a.apps.example.com", config); err == nil {
+		t.Errorf("newZone() accepted a short SOA template")
+	}
+}
+
+func TestEnsureZones(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	dp := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, "example.com")
+	dp.config.CreateZones = true
+	dp.config.CreateZoneParents = []string{"apps.example.org"}
+	dp.config.CreateZoneNS = []string{"ns1.example.org"}
+	dp.config.CreateZoneSOA = "{ns} hostmaster.{zone} 1 3600 900 604800 300"
+	dp.zoneCache = newZoneCache(time.Hour, time.Hour)
+
+	// team-b is known to exist, so only team-a is looked up and created
+	dp.zoneCache.set(ZoneKey{View: "default", Zone: "team-b.apps.example.org"}, true)
+	j := newJournal(dp.client)
+	err := dp.ensureZones(context.Background(), j, []*endpoint.Endpoint{
+		endpoint.NewEndpoint("web.team-a.apps.example.org", "A", "1.1.1.1"),
+		endpoint.NewEndpoint("web.team-b.apps.example.org", "A", "1.1.1.1"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		http.MethodGet + " /openapi/dns/zone/auth/view/default/zone/team-a.apps.example.org",
+		http.MethodPost + " /openapi/dns/zone/auth/view/default",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("ensureZones() requests = %v, want %v", calls, want)
+	}
+
+	zk := ZoneKey{View: "default", Zone: "team-a.apps.example.org"}
+	if !reflect.DeepEqual(j.zones, []ZoneKey{zk}) {
+		t.Errorf("ensureZones() journal zones = %v, want %v", j.zones, []ZoneKey{zk})
+	}
+	if exist, ok := dp.zoneCache.get(zk); !exist || !ok {
+		t.Errorf("zone cache of %v = %v, %v, want true, true", zk, exist, ok)
+	}
+}
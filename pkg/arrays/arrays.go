@@ -8,3 +8,19 @@ func Contains[T comparable](arr []T, v T) bool {
 	}
 	return false
 }
+
+// Chunk splits arr into consecutive chunks of at most size items.
+// A size below 1 returns arr as a single chunk.
+func Chunk[T any](arr []T, size int) [][]T {
+	if size < 1 || len(arr) <= size {
+		return [][]T{arr}
+	}
+
+	chunks := make([][]T, 0, (len(arr)+size-1)/size)
+	for size < len(arr) {
+		chunks = append(chunks, arr[:size:size])
+		arr = arr[size:]
+	}
+
+	return append(chunks, arr)
+}
@@ -1,6 +1,9 @@
 package arrays
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestContains(t *testing.T) {
 	type args struct {
@@ -37,3 +40,47 @@ func TestContains(t *testing.T) {
 		})
 	}
 }
+
+func TestChunk(t *testing.T) {
+	type args struct {
+		arr  []int
+		size int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]int
+	}{
+		{
+			name: "even",
+			args: args{
+				arr:  []int{1, 2, 3, 4},
+				size: 2,
+			},
+			want: [][]int{{1, 2}, {3, 4}},
+		},
+		{
+			name: "remainder",
+			args: args{
+				arr:  []int{1, 2, 3},
+				size: 2,
+			},
+			want: [][]int{{1, 2}, {3}},
+		},
+		{
+			name: "unlimited",
+			args: args{
+				arr:  []int{1, 2, 3},
+				size: 0,
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chunk(tt.args.arr, tt.args.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -107,7 +107,8 @@ func (p *Webhook) Records(w http.ResponseWriter, r *http.Request) {
 	records, err := p.provider.Records(ctx)
 	if err != nil {
 		requestLog(r).WithField(logFieldError, err).Error("error getting records")
-		w.WriteHeader(http.StatusInternalServerError)
+		spanError(r, err)
+		writeError(w, r, err)
 		return
 	}
 
@@ -146,8 +147,9 @@ func (p *Webhook) ApplyChanges(w http.ResponseWriter, r *http.Request) {
 	requestLog(r).Debugf("requesting apply changes, create: %d , updateOld: %d, updateNew: %d, delete: %d",
 		len(changes.Create), len(changes.UpdateOld), len(changes.UpdateNew), len(changes.Delete))
 	if err := p.provider.ApplyChanges(ctx, &changes); err != nil {
-		w.Header().Set(contentTypeHeader, contentTypePlaintext)
-		w.WriteHeader(http.StatusInternalServerError)
+		requestLog(r).WithField(logFieldError, err).Error("error applying changes")
+		spanError(r, err)
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -180,6 +182,7 @@ func (p *Webhook) AdjustEndpoints(w http.ResponseWriter, r *http.Request) {
 	log.Debugf("requesting adjust endpoints count: %d", len(pve))
 	pve, err := p.provider.AdjustEndpoints(pve)
 	if err != nil {
+		spanError(r, err)
 		w.Header().Set(contentTypeHeader, contentTypePlaintext)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -215,6 +218,138 @@ func (p *Webhook) Negotiate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// zoneCacheInvalidator is implemented by providers that cache zone lookups.
+type zoneCacheInvalidator interface {
+	InvalidateZoneCache()
+}
+
+// InvalidateZoneCache handles the post request for dropping cached zone lookups and records
+func (p *Webhook) InvalidateZoneCache(w http.ResponseWriter, r *http.Request) {
+	inv, ok := p.provider.(zoneCacheInvalidator)
+	if !ok {
+		requestLog(r).Debug("provider has no zone cache")
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	inv.InvalidateZoneCache()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dryRunInspector is implemented by providers that can report the requests
+// they planned without sending them.
+type dryRunInspector interface {
+	PlannedRequests() any
+}
+
+// PlannedRequests handles the get request for the requests planned in dry run mode
+func (p *Webhook) PlannedRequests(w http.ResponseWriter, r *http.Request) {
+	dri, ok := p.provider.(dryRunInspector)
+	if !ok {
+		requestLog(r).Debug("provider has no dry run mode")
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	w.Header().Set(contentTypeHeader, "application/json")
+	if err := json.NewEncoder(w).Encode(dri.PlannedRequests()); err != nil {
+		requestLog(r).WithField(logFieldError, err).Error("error encoding planned requests")
+	}
+}
+
+// readinessChecker is implemented by providers that can tell whether their
+// backend is reachable. The status is added to the readiness output.
+type readinessChecker interface {
+	Ready() (string, error)
+}
+
+// Readiness handles the get request for the readiness of the provider
+func (p *Webhook) Readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentTypeHeader, contentTypePlaintext)
+
+	body := "OK"
+	if rc, ok := p.provider.(readinessChecker); ok {
+		status, err := rc.Ready()
+		if err != nil {
+			requestLog(r).WithField(logFieldError, err).Warn("provider is not ready")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		if status != "" {
+			body += " " + status
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body))
+}
+
+// classifiedError is implemented by provider errors that know whether the
+// failed request may succeed when repeated.
+type classifiedError interface {
+	error
+	Retryable() bool
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+	Details   []any  `json:"details,omitempty"`
+}
+
+// writeError writes err as a JSON error response. external-dns exits on any
+// status outside 500-510, so every error is answered with a 5xx status: 503
+// if repeating the request may help, 500 otherwise. Whether the failure is
+// permanent is reported in the body only.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	ces := classifiedErrors(err)
+	// An error nobody classified is not known to be temporary
+	resp := errorResponse{Error: err.Error(), Retryable: len(ces) > 0}
+	for _, ce := range ces {
+		resp.Retryable = resp.Retryable && ce.Retryable()
+		resp.Details = append(resp.Details, ce)
+	}
+
+	status := http.StatusInternalServerError
+	if resp.Retryable {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set(contentTypeHeader, "application/json")
+	w.WriteHeader(status)
+	if writeErr := json.NewEncoder(w).Encode(resp); writeErr != nil {
+		requestLog(r).WithField(logFieldError, writeErr).Error("error writing error message to response writer")
+	}
+}
+
+// classifiedErrors returns the classified errors in the tree of err, outer
+// errors first, following both wrapped and joined errors.
+func classifiedErrors(err error) []classifiedError {
+	var ces []classifiedError
+	var walk func(error)
+	walk = func(err error) {
+		if ce, ok := err.(classifiedError); ok {
+			ces = append(ces, ce)
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if inner := u.Unwrap(); inner != nil {
+				walk(inner)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range u.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	walk(err)
+
+	return ces
+}
+
 func requestLog(r *http.Request) *log.Entry {
 	return log.WithFields(log.Fields{logFieldRequestMethod: r.Method, logFieldRequestPath: r.URL.Path})
 }
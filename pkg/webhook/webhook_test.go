@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testError struct {
+	Name      string `json:"name"`
+	retryable bool
+}
+
+func (e *testError) Error() string   { return e.Name }
+func (e *testError) Retryable() bool { return e.retryable }
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantRetryable bool
+		wantDetails   int
+	}{
+		{
+			name:          "unclassified",
+			err:           errors.New("boom"),
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: false,
+		},
+		{
+			name:          "retryable",
+			err:           fmt.Errorf("refresh zones: %w", &testError{Name: "outage", retryable: true}),
+			wantStatus:    http.StatusServiceUnavailable,
+			wantRetryable: true,
+			wantDetails:   1,
+		},
+		{
+			name:          "permanent",
+			err:           &testError{Name: "rejected"},
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: false,
+			wantDetails:   1,
+		},
+		{
+			name: "joined",
+			err: errors.Join(
+				fmt.Errorf("chunk 1/2: %w", &testError{Name: "outage", retryable: true}),
+				fmt.Errorf("chunk 2/2: %w", &testError{Name: "rejected"}),
+			),
+			wantStatus:    http.StatusInternalServerError,
+			wantRetryable: false,
+			wantDetails:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, httptest.NewRequest(http.MethodPost, "/records", nil), tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("writeError() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp errorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != tt.err.Error() || resp.Retryable != tt.wantRetryable || len(resp.Details) != tt.wantDetails {
+				t.Errorf("writeError() body = %+v, want retryable %v and %d details", resp, tt.wantRetryable, tt.wantDetails)
+			}
+		})
+	}
+}